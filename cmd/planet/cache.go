@@ -0,0 +1,511 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+)
+
+// cacheCommand implements the "cache" command group
+func cacheCommand(args []string) {
+	if len(args) < 2 {
+		fmt.Fprintf(os.Stderr, "Error: missing cache subcommand\n\n")
+		printUsage()
+		os.Exit(1)
+	}
+
+	switch args[1] {
+	case "migrate":
+		cacheMigrateCommand(args[1:])
+	case "gc":
+		cacheGCCommand(args[1:])
+	case "list":
+		cacheListCommand(args[1:])
+	case "show":
+		cacheShowCommand(args[1:])
+	case "purge":
+		cachePurgeCommand(args[1:])
+	case "export":
+		cacheExportCommand(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown cache subcommand %q\n\n", args[1])
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+func cacheMigrateCommand(args []string) {
+	fs := flag.NewFlagSet("cache migrate", flag.ExitOnError)
+	configPath := fs.String("c", "config.ini", "path to config file")
+	debugMode := fs.Bool("debug", false, "enable debug logging (overrides config log_level)")
+	to := fs.String("to", cache.BackendBolt, "backend to convert the cache to (json or bolt)")
+
+	fs.Parse(args[1:])
+
+	if err := runCacheMigrate(*configPath, *debugMode, *to); err != nil {
+		slog.Error("failed to migrate cache", "error", err)
+		os.Exit(1)
+	}
+}
+
+// runCacheMigrate implements the "cache migrate" command - copy the cache
+// from the configured backend to another one
+func runCacheMigrate(configPath string, debugMode bool, to string) error {
+	cfg, err := loadConfig(configPath, debugMode)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	from := cfg.Planet.CacheBackend
+	if from == to {
+		return fmt.Errorf("cache already uses the %s backend", to)
+	}
+
+	copied, err := cache.Migrate(cfg.Planet.CacheDirectory, from, to)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Copied %d feeds from the %s cache to the %s cache in %s\n", copied, from, to, cfg.Planet.CacheDirectory)
+	fmt.Printf("Set \"cache_backend = %s\" in the [Planet] section of %s to use it.\n", to, configPath)
+	fmt.Printf("The %s cache was left in place and can be removed once the new one works.\n", from)
+	return nil
+}
+
+func cacheGCCommand(args []string) {
+	fs := flag.NewFlagSet("cache gc", flag.ExitOnError)
+	configPath := fs.String("c", "config.ini", "path to config file")
+	debugMode := fs.Bool("debug", false, "enable debug logging (overrides config log_level)")
+	deleteFiles := fs.Bool("delete", false, "delete the orphaned cache data instead of only listing it")
+
+	fs.Parse(args[1:])
+
+	if err := runCacheGC(*configPath, *debugMode, *deleteFiles); err != nil {
+		slog.Error("failed to collect cache garbage", "error", err)
+		os.Exit(1)
+	}
+}
+
+// runCacheGC implements the "cache gc" command - list (and optionally delete)
+// cached archives and raw files of feeds that are no longer configured
+func runCacheGC(configPath string, debugMode, deleteFiles bool) error {
+	cfg, err := loadConfig(configPath, debugMode)
+	if err != nil {
+		return err
+	}
+
+	// Taken even when only listing, as opening the cache may migrate it
+	unlock, err := lockCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cacheInstance, err := newCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer cacheInstance.Close()
+
+	orphans, err := cacheInstance.Orphans(cfg.FeedURLs())
+	if err != nil {
+		return err
+	}
+
+	if len(orphans) == 0 {
+		fmt.Println("No orphaned cache data found.")
+		return nil
+	}
+
+	removed := 0
+	for _, orphan := range orphans {
+		what := fmt.Sprintf("raw file %s", orphan.Path)
+		if orphan.FeedURL != "" {
+			what = fmt.Sprintf("archive of %s (%d entries)", orphan.FeedURL, orphan.Entries)
+		}
+
+		if !deleteFiles {
+			fmt.Printf("orphaned %s\n", what)
+			continue
+		}
+		if err := cacheInstance.RemoveOrphan(orphan); err != nil {
+			slog.Warn("failed to remove orphaned cache data", "url", orphan.FeedURL, "path", orphan.Path, "error", err)
+			continue
+		}
+		fmt.Printf("removed  %s\n", what)
+		removed++
+	}
+
+	if !deleteFiles {
+		fmt.Printf("\n%d orphaned archives and raw files (run with -delete to remove them)\n", len(orphans))
+		return nil
+	}
+	fmt.Printf("\nRemoved %d of %d orphaned archives and raw files\n", removed, len(orphans))
+	return nil
+}
+
+// parseWithFeedURL parses flags around a single feed URL argument, so that
+// both "show -c config.ini URL" and "show URL -c config.ini" work
+func parseWithFeedURL(fs *flag.FlagSet, args []string) string {
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "Error: missing feed URL\n\n")
+		fs.Usage()
+		os.Exit(2)
+	}
+	feedURL := fs.Arg(0)
+	fs.Parse(fs.Args()[1:])
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Error: unexpected arguments %v\n\n", fs.Args())
+		fs.Usage()
+		os.Exit(2)
+	}
+	return feedURL
+}
+
+func cacheListCommand(args []string) {
+	fs := flag.NewFlagSet("cache list", flag.ExitOnError)
+	configPath := fs.String("c", "config.ini", "path to config file")
+	debugMode := fs.Bool("debug", false, "enable debug logging (overrides config log_level)")
+
+	fs.Parse(args[1:])
+
+	if err := runCacheList(*configPath, *debugMode); err != nil {
+		slog.Error("failed to list cache", "error", err)
+		os.Exit(1)
+	}
+}
+
+// runCacheList implements the "cache list" command - list cached feeds with
+// their entry counts and HTTP caching metadata
+func runCacheList(configPath string, debugMode bool) error {
+	cfg, err := loadConfig(configPath, debugMode)
+	if err != nil {
+		return err
+	}
+
+	// Opening the cache may migrate it
+	unlock, err := lockCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cacheInstance, err := newCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer cacheInstance.Close()
+
+	urls, err := cacheInstance.FeedURLs()
+	if err != nil {
+		return err
+	}
+
+	configured := make(map[string]bool, len(cfg.Feeds))
+	for _, feed := range cfg.Feeds {
+		configured[feed.URL] = true
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ENTRIES\tLAST FETCHED\tETAG\tFEED")
+	total := 0
+	for _, feedURL := range urls {
+		cached, err := cacheInstance.Load(feedURL)
+		if err != nil {
+			slog.Warn("failed to load cached feed", "url", feedURL, "error", err)
+			continue
+		}
+		if cached == nil {
+			continue
+		}
+		total += len(cached.Entries)
+
+		feed := feedURL
+		if !configured[feedURL] {
+			feed += " (not configured)"
+		}
+		etag := cached.Metadata.ETag
+		if etag == "" {
+			etag = "-"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", len(cached.Entries), formatAge(cached.Metadata.LastFetched, now), etag, feed)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+
+	fmt.Printf("\n%d feeds, %d entries (%s backend in %s)\n", len(urls), total, cfg.Planet.CacheBackend, cfg.Planet.CacheDirectory)
+	return nil
+}
+
+func cacheShowCommand(args []string) {
+	fs := flag.NewFlagSet("cache show", flag.ExitOnError)
+	configPath := fs.String("c", "config.ini", "path to config file")
+	debugMode := fs.Bool("debug", false, "enable debug logging (overrides config log_level)")
+	jsonOutput := fs.Bool("json", false, "print the whole cached archive as JSON")
+
+	feedURL := parseWithFeedURL(fs, args[1:])
+
+	if err := runCacheShow(*configPath, *debugMode, feedURL, *jsonOutput); err != nil {
+		slog.Error("failed to show cached feed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// runCacheShow implements the "cache show" command - print a feed's cached
+// metadata, health and entries
+func runCacheShow(configPath string, debugMode bool, feedURL string, jsonOutput bool) error {
+	cfg, err := loadConfig(configPath, debugMode)
+	if err != nil {
+		return err
+	}
+
+	// Opening the cache may migrate it
+	unlock, err := lockCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cacheInstance, err := newCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer cacheInstance.Close()
+
+	cached, err := cacheInstance.Load(feedURL)
+	if err != nil {
+		return err
+	}
+	if cached == nil {
+		return fmt.Errorf("feed %s is not cached", feedURL)
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(cached)
+	}
+
+	now := time.Now()
+	meta, health := cached.Metadata, cached.Health
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Feed:\t%s\n", feedURL)
+	fmt.Fprintf(w, "Last fetched:\t%s\n", formatTime(meta.LastFetched, now))
+	fmt.Fprintf(w, "ETag:\t%s\n", meta.ETag)
+	fmt.Fprintf(w, "Last-Modified:\t%s\n", meta.LastModified)
+	if meta.MovedTo != "" {
+		fmt.Fprintf(w, "Moved to:\t%s\n", meta.MovedTo)
+	}
+	if meta.Gone {
+		fmt.Fprintf(w, "Gone:\tyes (410)\n")
+	}
+	fmt.Fprintf(w, "Last success:\t%s\n", formatTime(health.LastSuccess, now))
+	fmt.Fprintf(w, "Last new entry:\t%s\n", formatTime(health.LastNewEntry, now))
+	fmt.Fprintf(w, "Failures:\t%d\n", health.ConsecutiveFailures)
+	if health.ConsecutiveFailures > 0 {
+		fmt.Fprintf(w, "Last error:\t%s (%s)\n", health.LastError, formatTime(health.LastErrorAt, now))
+	}
+	if now.Before(health.SkipUntil) {
+		fmt.Fprintf(w, "Skipped until:\t%s\n", formatDate(health.SkipUntil))
+	}
+	fmt.Fprintf(w, "Avg latency:\t%s\n", formatLatency(health.AvgLatencyMS))
+	fmt.Fprintf(w, "Entries:\t%d\n", len(cached.Entries))
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+
+	if len(cached.Entries) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tFIRST SEEN\tBACKLOG\tID\tTITLE")
+	for _, entry := range cached.Entries {
+		backlog := ""
+		if entry.Backlog {
+			backlog = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			formatDate(entry.Date),
+			formatDate(entry.FirstSeen),
+			backlog,
+			entry.ID,
+			entry.Title)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	return nil
+}
+
+func cachePurgeCommand(args []string) {
+	fs := flag.NewFlagSet("cache purge", flag.ExitOnError)
+	configPath := fs.String("c", "config.ini", "path to config file")
+	debugMode := fs.Bool("debug", false, "enable debug logging (overrides config log_level)")
+
+	feedURL := parseWithFeedURL(fs, args[1:])
+
+	if err := runCachePurge(*configPath, *debugMode, feedURL); err != nil {
+		slog.Error("failed to purge cached feed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// runCachePurge implements the "cache purge" command - remove a feed's
+// cached archive
+func runCachePurge(configPath string, debugMode bool, feedURL string) error {
+	cfg, err := loadConfig(configPath, debugMode)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cacheInstance, err := newCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer cacheInstance.Close()
+
+	purged, err := cacheInstance.Purge(feedURL)
+	if err != nil {
+		return err
+	}
+	if !purged {
+		return fmt.Errorf("feed %s is not cached", feedURL)
+	}
+
+	fmt.Printf("Purged %s from the cache; the next fetch treats it as a new feed.\n", feedURL)
+	return nil
+}
+
+func cacheExportCommand(args []string) {
+	fs := flag.NewFlagSet("cache export", flag.ExitOnError)
+	configPath := fs.String("c", "config.ini", "path to config file")
+	debugMode := fs.Bool("debug", false, "enable debug logging (overrides config log_level)")
+	format := fs.String("format", "jsonl", "export format (jsonl: one entry per line)")
+	output := fs.String("o", "", "write to this file instead of stdout")
+
+	fs.Parse(args[1:])
+
+	if err := runCacheExport(*configPath, *debugMode, *format, *output); err != nil {
+		slog.Error("failed to export cache", "error", err)
+		os.Exit(1)
+	}
+}
+
+// runCacheExport implements the "cache export" command - write every cached
+// entry, including backlog entries, one JSON object per line
+func runCacheExport(configPath string, debugMode bool, format, output string) error {
+	if format != "jsonl" {
+		return fmt.Errorf("unsupported export format %q (supported: jsonl)", format)
+	}
+
+	cfg, err := loadConfig(configPath, debugMode)
+	if err != nil {
+		return err
+	}
+
+	// Opening the cache may migrate it
+	unlock, err := lockCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cacheInstance, err := newCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer cacheInstance.Close()
+
+	urls, err := cacheInstance.FeedURLs()
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		_, err := exportEntries(os.Stdout, cacheInstance, urls)
+		return err
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("create export file: %w", err)
+	}
+	exported, err := exportEntries(file, cacheInstance, urls)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("close export file: %w", closeErr)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d entries from %d feeds to %s\n", exported, len(urls), output)
+	return nil
+}
+
+// exportEntries writes the cached entries of the feeds to w, one JSON object
+// per line, and returns the number of entries written
+func exportEntries(w io.Writer, cacheInstance *cache.Cache, urls []string) (int, error) {
+	buffered := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buffered)
+	exported := 0
+	for _, feedURL := range urls {
+		cached, err := cacheInstance.Load(feedURL)
+		if err != nil {
+			slog.Warn("failed to load cached feed", "url", feedURL, "error", err)
+			continue
+		}
+		if cached == nil {
+			continue
+		}
+		for _, entry := range cached.Entries {
+			if err := encoder.Encode(entry); err != nil {
+				return exported, fmt.Errorf("write entry: %w", err)
+			}
+			exported++
+		}
+	}
+	if err := buffered.Flush(); err != nil {
+		return exported, fmt.Errorf("write export: %w", err)
+	}
+	return exported, nil
+}
+
+// formatTime formats a time with its age, or "never"
+func formatTime(t, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Local().Format("2006-01-02 15:04:05") + " (" + formatAge(t, now) + ")"
+}
+
+// formatDate formats an entry date, or "-" if it is unknown
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
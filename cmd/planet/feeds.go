@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alexey-ott/planet-go/internal/atomicfile"
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+// feedsCommand implements the "feeds" command group
+func feedsCommand(args []string) {
+	if len(args) < 2 {
+		fmt.Fprintf(os.Stderr, "Error: missing feeds subcommand\n\n")
+		printUsage()
+		os.Exit(1)
+	}
+
+	switch args[1] {
+	case "fix":
+		feedsFixCommand(args[1:])
+	case "status":
+		feedsStatusCommand(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown feeds subcommand %q\n\n", args[1])
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+func feedsFixCommand(args []string) {
+	fs := flag.NewFlagSet("feeds fix", flag.ExitOnError)
+	configPath := fs.String("c", "config.ini", "path to config file")
+	debugMode := fs.Bool("debug", false, "enable debug logging (overrides config log_level)")
+	dryRun := fs.Bool("dry-run", false, "report changes without rewriting the config")
+
+	fs.Parse(args[1:])
+
+	if err := runFeedsFix(*configPath, *debugMode, *dryRun); err != nil {
+		slog.Error("failed to fix feeds", "error", err)
+		os.Exit(1)
+	}
+}
+
+// runFeedsFix implements the "feeds fix" command - apply permanent redirects
+// and 410 Gone responses recorded in the cache to the config file
+func runFeedsFix(configPath string, debugMode, dryRun bool) error {
+	cfg, err := loadConfig(configPath, debugMode)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cacheInstance, err := newCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer cacheInstance.Close()
+
+	// Collect subscription changes recorded by the fetcher
+	var fixes []config.FeedFix
+	for _, feed := range cfg.Feeds {
+		meta, err := cacheInstance.LoadMetadata(feed.URL)
+		if err != nil {
+			slog.Warn("failed to load cache metadata", "url", feed.URL, "error", err)
+			continue
+		}
+		if meta == nil || (!meta.Gone && meta.MovedTo == "") {
+			continue
+		}
+		fixes = append(fixes, config.FeedFix{URL: feed.URL, MovedTo: meta.MovedTo, Gone: meta.Gone})
+	}
+
+	if len(fixes) == 0 {
+		fmt.Println("No moved or gone feeds found.")
+		return nil
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+
+	note := "planet feeds fix " + time.Now().Format("2006-01-02")
+	fixed, results := config.FixFeeds(data, fixes, note)
+
+	// Report
+	applied := 0
+	for _, fix := range results {
+		status := "skipped"
+		if fix.Applied {
+			status = "fixed"
+			applied++
+		}
+		fmt.Printf("%-7s %s: %s\n", status, fix.URL, fix.Action)
+	}
+
+	if dryRun {
+		fmt.Printf("\n%d of %d feeds would be changed (dry run, config not modified)\n", applied, len(results))
+		return nil
+	}
+
+	if applied == 0 {
+		return nil
+	}
+
+	// Keep a backup of the original config
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return fmt.Errorf("stat config: %w", err)
+	}
+	if err := atomicfile.WriteFile(configPath+".bak", data, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("write config backup: %w", err)
+	}
+	if err := atomicfile.WriteFile(configPath, fixed, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+
+	// Move cached archives of renamed feeds to their new URL
+	for _, fix := range results {
+		if !fix.Applied || fix.Gone || fix.MovedTo == "" {
+			continue
+		}
+		if err := cacheInstance.Move(fix.URL, fix.MovedTo); err != nil {
+			slog.Warn("failed to move cached feed", "url", fix.URL, "moved_to", fix.MovedTo, "error", err)
+		}
+	}
+
+	fmt.Printf("\n%d of %d feeds changed in %s (backup: %s.bak)\n", applied, len(results), configPath, configPath)
+	return nil
+}
+
+func feedsStatusCommand(args []string) {
+	fs := flag.NewFlagSet("feeds status", flag.ExitOnError)
+	configPath := fs.String("c", "config.ini", "path to config file")
+	debugMode := fs.Bool("debug", false, "enable debug logging (overrides config log_level)")
+	jsonOutput := fs.Bool("json", false, "print the report as JSON")
+
+	fs.Parse(args[1:])
+
+	if err := runFeedsStatus(*configPath, *debugMode, *jsonOutput); err != nil {
+		slog.Error("failed to report feed status", "error", err)
+		os.Exit(1)
+	}
+}
+
+// feedStatus is one line of the "feeds status" report
+type feedStatus struct {
+	URL    string       `json:"url"`
+	Name   string       `json:"name"`
+	Status string       `json:"status"` // ok, failing, skipped, moved, gone or new
+	Health cache.Health `json:"health"`
+}
+
+// statusOrder sorts the report so that feeds needing attention come first
+var statusOrder = map[string]int{"skipped": 0, "gone": 1, "failing": 2, "moved": 3, "new": 4, "ok": 5}
+
+// runFeedsStatus implements the "feeds status" command - report the health
+// of every configured feed
+func runFeedsStatus(configPath string, debugMode, jsonOutput bool) error {
+	cfg, err := loadConfig(configPath, debugMode)
+	if err != nil {
+		return err
+	}
+
+	// Opening the cache may migrate it
+	unlock, err := lockCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cacheInstance, err := newCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer cacheInstance.Close()
+	now := time.Now()
+
+	report := make([]feedStatus, 0, len(cfg.Feeds))
+	for _, feed := range cfg.Feeds {
+		meta, err := cacheInstance.LoadMetadata(feed.URL)
+		if err != nil {
+			slog.Warn("failed to load cache metadata", "url", feed.URL, "error", err)
+		}
+		health, err := cacheInstance.LoadHealth(feed.URL)
+		if err != nil {
+			slog.Warn("failed to load feed health", "url", feed.URL, "error", err)
+		}
+
+		status := "ok"
+		switch {
+		case meta != nil && meta.Gone:
+			status = "gone"
+		case now.Before(health.SkipUntil):
+			status = "skipped"
+		case health.ConsecutiveFailures > 0:
+			status = "failing"
+		case meta != nil && meta.MovedTo != "":
+			status = "moved"
+		case health.LastSuccess.IsZero():
+			status = "new"
+		}
+
+		report = append(report, feedStatus{URL: feed.URL, Name: feed.Name, Status: status, Health: health})
+	}
+
+	sort.SliceStable(report, func(i, j int) bool {
+		if statusOrder[report[i].Status] != statusOrder[report[j].Status] {
+			return statusOrder[report[i].Status] < statusOrder[report[j].Status]
+		}
+		return report[i].Health.ConsecutiveFailures > report[j].Health.ConsecutiveFailures
+	})
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tFAILS\tLAST SUCCESS\tLAST NEW ENTRY\tLATENCY\tFEED\tLAST ERROR")
+	counts := make(map[string]int)
+	for _, line := range report {
+		counts[line.Status]++
+
+		lastError := ""
+		if line.Health.ConsecutiveFailures > 0 {
+			lastError = line.Health.LastError
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			line.Status,
+			line.Health.ConsecutiveFailures,
+			formatAge(line.Health.LastSuccess, now),
+			formatAge(line.Health.LastNewEntry, now),
+			formatLatency(line.Health.AvgLatencyMS),
+			line.URL,
+			lastError)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+
+	fmt.Printf("\n%d feeds: %d ok, %d failing, %d skipped, %d moved, %d gone, %d never fetched\n",
+		len(report), counts["ok"], counts["failing"], counts["skipped"], counts["moved"], counts["gone"], counts["new"])
+	return nil
+}
+
+// formatAge formats a past time as a short age such as "3h ago" or "12d ago"
+func formatAge(t, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	age := now.Sub(t)
+	switch {
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age.Minutes()))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(age.Hours()/24))
+	}
+}
+
+// formatLatency formats an average latency in milliseconds
+func formatLatency(ms float64) string {
+	if ms == 0 {
+		return "-"
+	}
+	return (time.Duration(ms) * time.Millisecond).String()
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alexey-ott/planet-go/internal/filter"
+)
+
+// filterCommand implements the "filter" command group
+func filterCommand(args []string) {
+	if len(args) < 2 {
+		fmt.Fprintf(os.Stderr, "Error: missing filter subcommand\n\n")
+		printUsage()
+		os.Exit(1)
+	}
+
+	switch args[1] {
+	case "explain":
+		filterExplainCommand(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown filter subcommand %q\n\n", args[1])
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+func filterExplainCommand(args []string) {
+	fs := flag.NewFlagSet("filter explain", flag.ExitOnError)
+	configPath := fs.String("c", "config.ini", "path to config file")
+	debugMode := fs.Bool("debug", false, "enable debug logging (overrides config log_level)")
+	jsonOutput := fs.Bool("json", false, "print the report as JSON")
+
+	fs.Parse(args[1:])
+
+	if err := runFilterExplain(*configPath, *debugMode, *jsonOutput); err != nil {
+		slog.Error("failed to explain filters", "error", err)
+		os.Exit(1)
+	}
+}
+
+// filteredEntry is one entry of the "filter explain" report
+type filteredEntry struct {
+	Feed    string          `json:"feed"`
+	FeedURL string          `json:"feed_url"`
+	Title   string          `json:"title"`
+	Link    string          `json:"link"`
+	ID      string          `json:"id"`
+	Date    time.Time       `json:"date"`
+	Rule    string          `json:"rule"`
+	Reasons []filter.Reason `json:"reasons"`
+}
+
+// runFilterExplain implements the "filter explain" command - apply the
+// configured filters to the cached entries and report the dropped ones
+func runFilterExplain(configPath string, debugMode, jsonOutput bool) error {
+	cfg, err := loadConfig(configPath, debugMode)
+	if err != nil {
+		return err
+	}
+
+	// Opening the cache may migrate it
+	unlock, err := lockCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cacheInstance, err := newCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer cacheInstance.Close()
+
+	entries, err := cacheInstance.LoadAll(cfg.FeedURLs())
+	if err != nil {
+		return fmt.Errorf("load cached entries: %w", err)
+	}
+
+	rejections, err := filter.Explain(entries, cfg.Feeds, cfg.Planet.Filter, cfg.Planet.Exclude, cfg.Planet.FilterMode)
+	if err != nil {
+		return err
+	}
+
+	report := make([]filteredEntry, 0, len(rejections))
+	for _, rejection := range rejections {
+		entry := rejection.Entry
+		report = append(report, filteredEntry{
+			Feed:    entry.ChannelName,
+			FeedURL: entry.ChannelURL,
+			Title:   entry.Title,
+			Link:    entry.Link,
+			ID:      entry.ID,
+			Date:    entry.Date,
+			Rule:    rejection.Rule,
+			Reasons: rejection.Reasons,
+		})
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	for _, line := range report {
+		fmt.Printf("%s: %s (%s)\n", line.Feed, line.Title, formatDate(line.Date))
+		if line.Link != "" {
+			fmt.Printf("  link:   %s\n", line.Link)
+		}
+		fmt.Printf("  rule:   %s\n", line.Rule)
+		for _, reason := range line.Reasons {
+			fmt.Printf("  reason: %s\n", describeReason(reason))
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("%d of %d cached entries filtered out\n", len(report), len(entries))
+	return nil
+}
+
+// describeReason formats a rejecting pattern and the text it matched
+func describeReason(reason filter.Reason) string {
+	if reason.Kind == filter.KindFilter {
+		return fmt.Sprintf("not matched by filter %q", reason.Pattern)
+	}
+
+	description := fmt.Sprintf("matched by exclude %q", reason.Pattern)
+	matches := make([]string, 0, len(reason.Spans))
+	for _, span := range reason.Spans {
+		matches = append(matches, fmt.Sprintf("%s[%d:%d] %q", span.Field, span.Start, span.End, span.Text))
+	}
+	if len(matches) > 0 {
+		description += " at " + strings.Join(matches, ", ")
+	}
+	return description
+}
@@ -6,16 +6,23 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/alexey-ott/planet-go/internal/cache"
 	"github.com/alexey-ott/planet-go/internal/config"
+	"github.com/alexey-ott/planet-go/internal/dedupe"
 	"github.com/alexey-ott/planet-go/internal/fetcher"
 	"github.com/alexey-ott/planet-go/internal/filter"
+	"github.com/alexey-ott/planet-go/internal/plugin"
 	"github.com/alexey-ott/planet-go/internal/renderer"
+	"github.com/alexey-ott/planet-go/internal/sanitizer"
+	"github.com/alexey-ott/planet-go/internal/transform"
 	"github.com/alexey-ott/planet-go/internal/twitter"
 )
 
@@ -38,6 +45,12 @@ func main() {
 		renderCommand(os.Args[1:])
 	case "post":
 		postCommand(os.Args[1:])
+	case "feeds":
+		feedsCommand(os.Args[1:])
+	case "cache":
+		cacheCommand(os.Args[1:])
+	case "filter":
+		filterCommand(os.Args[1:])
 	case "version":
 		versionCommand()
 	case "-version", "--version":
@@ -63,11 +76,31 @@ Usage:
   planet [command] [options]
 
 Commands:
-  run      Fetch feeds, render templates, and post to Twitter (default)
-  fetch    Fetch feeds and update cache only (no posting)
-  render   Render templates from cache only (no posting)
-  post     Post new articles to Twitter from cache (no fetching)
-  version  Show version information
+  run        Fetch feeds, render templates, and post to Twitter (default)
+  fetch      Fetch feeds and update cache only (no posting)
+  render     Render templates from cache only (no posting)
+  post       Post new articles to Twitter from cache (no fetching)
+  feeds fix  Rename permanently moved feeds and comment out gone (410) feeds
+             in the config (-dry-run to only report)
+  feeds status
+             Report feed health: failures, last success, last new entry,
+             latency (-json for JSON output)
+  cache migrate
+             Copy the cache to another storage backend
+             (-to json|bolt, default bolt)
+  cache gc   List cached data of feeds no longer in the config
+             (-delete to remove it)
+  cache list List cached feeds with entry counts, last fetch and ETag
+  cache show <feed-url>
+             Show a feed's cached metadata, health and entries (-json)
+  cache purge <feed-url>
+             Remove a feed's cached archive
+  cache export
+             Export all cached entries (-format jsonl, -o file)
+  filter explain
+             List cached entries dropped by the filters, with the rule
+             and the matched text (-json for JSON output)
+  version    Show version information
 
 Options:
   -c string
@@ -81,6 +114,12 @@ Examples:
   planet fetch -c config.ini          # Only fetch and cache feeds (no posting)
   planet render -c config.ini         # Only render from cache (no posting)
   planet post -c config.ini           # Only post to Twitter from cache
+  planet feeds fix -c config.ini      # Apply moved/gone feeds to the config
+  planet cache migrate -to bolt       # Convert the cache to the bolt backend
+  planet cache gc -delete             # Remove cached data of removed feeds
+  planet cache show https://example.com/feed.xml
+                                      # Inspect a cached feed
+  planet filter explain -c config.ini # Show why entries are filtered out
   planet version                      # Show version
 
 For more information, visit: https://github.com/alexey-ott/planet-go
@@ -160,7 +199,8 @@ func loadConfig(configPath string, debugMode bool) (*config.Config, error) {
 	}
 
 	// Load configuration
-	fmt.Printf("Loading configuration from: %s\n", absPath)
+	// Printed to stderr so that stdout stays clean for reports such as "feeds status -json"
+	fmt.Fprintf(os.Stderr, "Loading configuration from: %s\n", absPath)
 	slog.Info("loading configuration", "path", configPath, "absolute_path", absPath)
 	cfg, err := config.Load(configPath)
 	if err != nil {
@@ -177,6 +217,67 @@ func loadConfig(configPath string, debugMode bool) (*config.Config, error) {
 	return cfg, nil
 }
 
+// newCache creates a cache manager configured from the [Planet] section and
+// applies pending cache migrations. Migrations write the cache, so the caller
+// must hold the cache lock (see lockCache). The cache must be closed after use.
+func newCache(cfg *config.Config) (*cache.Cache, error) {
+	cacheInstance, err := cache.Open(cfg.Planet.CacheDirectory, cfg.Planet.CacheBackend)
+	if err != nil {
+		return nil, fmt.Errorf("open cache: %w", err)
+	}
+	cacheInstance.SetRetention(cache.Retention{
+		MaxEntries: cfg.Planet.CacheKeepEntries,
+		MaxAge:     time.Duration(cfg.Planet.CacheKeepDays) * 24 * time.Hour,
+	})
+
+	// One-time migration of entries cached without an ID
+	if migrated, err := cacheInstance.MigrateEntryIDs(); err != nil {
+		slog.Warn("failed to migrate cached entry IDs", "error", err)
+	} else if migrated > 0 {
+		slog.Info("migrated cached entries to stable IDs", "entries", migrated)
+	}
+	return cacheInstance, nil
+}
+
+// lockCache takes the advisory lock on the cache directory so that
+// overlapping runs (e.g. from cron) do not write the cache at the same time.
+// The lock is also released when the process is interrupted. The returned
+// function releases the lock and stops watching for interrupts.
+func lockCache(cfg *config.Config) (func(), error) {
+	lock, err := cache.LockDirectory(cfg.Planet.CacheDirectory, cfg.Planet.LockTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+	unlock := func() {
+		once.Do(func() {
+			if err := lock.Unlock(); err != nil {
+				slog.Warn("failed to release cache lock", "error", err)
+			}
+		})
+	}
+
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			slog.Warn("interrupted, releasing cache lock", "signal", sig)
+			unlock()
+			os.Exit(1)
+		case <-done:
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+		unlock()
+	}, nil
+}
+
 // fetchFeeds fetches all feeds and returns timing info
 func fetchFeeds(cfg *config.Config, debugMode bool) (successCount, cachedCount, errorCount int, duration time.Duration, err error) {
 	// Ensure cache directory exists
@@ -185,7 +286,23 @@ func fetchFeeds(cfg *config.Config, debugMode bool) (successCount, cachedCount,
 	}
 
 	// Initialize components
-	cacheInstance := cache.New(cfg.Planet.CacheDirectory)
+	cacheInstance, err := newCache(cfg)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	defer cacheInstance.Close()
+
+	retryPolicy := fetcher.RetryPolicy{
+		MaxRetries: cfg.Planet.FetchRetries,
+		BaseDelay:  cfg.Planet.RetryBaseDelay,
+		MaxDelay:   cfg.Planet.RetryMaxDelay,
+	}
+
+	healthPolicy := fetcher.HealthPolicy{
+		MaxFailures: cfg.Planet.FailureThreshold,
+		BaseBackoff: cfg.Planet.FailureBackoff,
+		MaxBackoff:  cfg.Planet.FailureMaxBackoff,
+	}
 
 	// Select fetcher based on configuration
 	var fetcherInstance fetcher.Fetcher
@@ -193,14 +310,25 @@ func fetchFeeds(cfg *config.Config, debugMode bool) (successCount, cachedCount,
 		slog.Debug("initializing sequential fetcher",
 			"cache_dir", cfg.Planet.CacheDirectory,
 			"timeout", cfg.Planet.FeedTimeout)
-		fetcherInstance = fetcher.NewSequential(cfg.Planet.FeedTimeout, cacheInstance, debugMode)
+		sequentialFetcher := fetcher.NewSequential(cfg.Planet.FeedTimeout, cacheInstance, debugMode)
+		sequentialFetcher.SetRetryPolicy(retryPolicy)
+		sequentialFetcher.SetHealthPolicy(healthPolicy)
+		sequentialFetcher.SetNewFeedItems(cfg.Planet.NewFeedItems)
+		fetcherInstance = sequentialFetcher
 	} else {
 		// Default to parallel mode
 		slog.Debug("initializing parallel fetcher",
 			"cache_dir", cfg.Planet.CacheDirectory,
 			"timeout", cfg.Planet.FeedTimeout,
-			"workers", cfg.Planet.ParallelWorkers)
-		fetcherInstance = fetcher.NewParallel(cfg.Planet.FeedTimeout, cacheInstance, debugMode, cfg.Planet.ParallelWorkers)
+			"workers", cfg.Planet.ParallelWorkers,
+			"max_per_host", cfg.Planet.MaxPerHost,
+			"host_delay", cfg.Planet.HostDelay)
+		parallelFetcher := fetcher.NewParallel(cfg.Planet.FeedTimeout, cacheInstance, debugMode, cfg.Planet.ParallelWorkers)
+		parallelFetcher.SetHostLimits(cfg.Planet.MaxPerHost, cfg.Planet.HostDelay)
+		parallelFetcher.SetRetryPolicy(retryPolicy)
+		parallelFetcher.SetHealthPolicy(healthPolicy)
+		parallelFetcher.SetNewFeedItems(cfg.Planet.NewFeedItems)
+		fetcherInstance = parallelFetcher
 	}
 
 	// Log first few feeds at INFO level
@@ -235,7 +363,30 @@ func fetchFeeds(cfg *config.Config, debugMode bool) (successCount, cachedCount,
 	duration = time.Since(fetchStart)
 
 	// Process results
+	delayedCount := 0
+	retriedCount := 0
+	movedCount := 0
+	goneCount := 0
+	skippedCount := 0
 	for _, result := range results {
+		if result.Skipped {
+			skippedCount++
+			continue
+		}
+		if result.MovedTo != "" {
+			movedCount++
+		}
+		if result.Gone {
+			goneCount++
+		}
+		if result.Attempts > 1 {
+			retriedCount++
+			slog.Debug("feed fetched after retries", "url", result.URL, "attempts", result.Attempts)
+		}
+		if result.Delayed > 0 {
+			delayedCount++
+			slog.Debug("feed delayed by host limiter", "url", result.URL, "delay", result.Delayed)
+		}
 		if result.Error != nil {
 			errorCount++
 			slog.Error("feed failed", "url", result.URL, "error", result.Error)
@@ -254,19 +405,33 @@ func fetchFeeds(cfg *config.Config, debugMode bool) (successCount, cachedCount,
 		"success", successCount,
 		"cached", cachedCount,
 		"errors", errorCount,
+		"delayed", delayedCount,
+		"retried", retriedCount,
+		"skipped", skippedCount,
 		"duration", duration)
 
+	if movedCount > 0 || goneCount > 0 {
+		slog.Warn("some feeds moved permanently or are gone, run \"planet feeds fix\" to update the config",
+			"moved", movedCount,
+			"gone", goneCount)
+	}
+
 	return successCount, cachedCount, errorCount, duration, nil
 }
 
-// loadAndFilterEntries loads all cached entries and applies per-feed filters
+// loadAndFilterEntries loads all cached entries, applies per-feed filters and
+// sanitizes the entry HTML
 func loadAndFilterEntries(cfg *config.Config) ([]cache.Entry, error) {
-	cacheInstance := cache.New(cfg.Planet.CacheDirectory)
+	cacheInstance, err := newCache(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer cacheInstance.Close()
 
 	// Load all cached entries
 	slog.Debug("loading all cached entries")
 	loadStart := time.Now()
-	entries, err := cacheInstance.LoadAll()
+	entries, err := cacheInstance.LoadAll(cfg.FeedURLs())
 	loadDuration := time.Since(loadStart)
 
 	if err != nil {
@@ -290,7 +455,7 @@ func loadAndFilterEntries(cfg *config.Config) ([]cache.Entry, error) {
 		"feeds_count", len(cfg.Feeds))
 
 	filterStart := time.Now()
-	filtered, err := filter.ApplyPerFeed(entries, cfg.Feeds, cfg.Planet.Filter, cfg.Planet.Exclude)
+	filtered, err := filter.ApplyPerFeed(entries, cfg.Feeds, cfg.Planet.Filter, cfg.Planet.Exclude, cfg.Planet.FilterMode)
 	if err != nil {
 		return nil, fmt.Errorf("apply filters: %w", err)
 	}
@@ -308,7 +473,48 @@ func loadAndFilterEntries(cfg *config.Config) ([]cache.Entry, error) {
 			"duration", filterDuration)
 	}
 
-	return filtered, nil
+	// Run external filter programs
+	filtered = plugin.ApplyPerFeed(filtered, cfg.Feeds, cfg.Planet.Filters, cfg.Planet.FilterTimeout, cfg.Planet.CacheDirectory)
+
+	// Drop articles carried by several feeds
+	if cfg.Planet.Dedupe {
+		deduped, dropped, err := dedupe.Apply(filtered, cfg.Feeds, cfg.Planet.DedupeKeep)
+		if err != nil {
+			return nil, fmt.Errorf("dedupe entries: %w", err)
+		}
+		if dropped > 0 {
+			slog.Info("dropped duplicate entries",
+				"dropped", dropped,
+				"remaining", len(deduped),
+				"keep", cfg.Planet.DedupeKeep)
+		}
+		filtered = deduped
+	}
+
+	// Sanitize entry HTML before it reaches templates
+	var policy *sanitizer.Policy
+	if cfg.Planet.Sanitize {
+		policy = sanitizer.FromConfig(cfg.Planet)
+	} else {
+		slog.Info("HTML sanitization disabled (sanitize = false)")
+	}
+	sanitizeStart := time.Now()
+	sanitized := sanitizer.ApplyPerFeed(filtered, cfg.Feeds, policy)
+	slog.Debug("sanitized entries",
+		"count", len(sanitized),
+		"duration", time.Since(sanitizeStart))
+
+	// Rewrite sanitized content, e.g. make relative URLs absolute
+	transformStart := time.Now()
+	transformed, err := transform.ApplyPerFeed(sanitized, cfg.Feeds, cfg.Planet.Transformers)
+	if err != nil {
+		return nil, fmt.Errorf("transform entries: %w", err)
+	}
+	slog.Debug("transformed entries",
+		"count", len(transformed),
+		"duration", time.Since(transformStart))
+
+	return transformed, nil
 }
 
 // limitEntries returns the most recent N entries, sorted by date (newest first)
@@ -343,6 +549,10 @@ func sortEntriesByDate(entries []cache.Entry) []cache.Entry {
 
 // renderTemplates renders all configured templates
 func renderTemplates(cfg *config.Config, entries []cache.Entry, configPath string) (int, error) {
+	if err := renderer.CheckOutputs(cfg.Planet.TemplateFiles, cfg.Planet.Outputs); err != nil {
+		return 0, err
+	}
+
 	// Ensure output directory exists
 	if err := os.MkdirAll(cfg.Planet.OutputDir, 0755); err != nil {
 		return 0, fmt.Errorf("create output directory: %w", err)
@@ -389,11 +599,28 @@ func renderTemplates(cfg *config.Config, entries []cache.Entry, configPath strin
 				"duration", time.Since(tmplStart))
 		}
 	}
+
+	successOutputs := 0
+	for _, name := range cfg.Planet.Outputs {
+		outputStart := time.Now()
+		if err := rendererInstance.RenderOutput(name, entries, cfg); err != nil {
+			slog.Error("output failed",
+				"output", name,
+				"error", err,
+				"duration", time.Since(outputStart))
+		} else {
+			successOutputs++
+			slog.Info("output rendered",
+				"output", name,
+				"duration", time.Since(outputStart))
+		}
+	}
 	renderDuration := time.Since(renderStart)
 
 	slog.Info("render complete",
 		"entries", len(entries),
 		"templates", successTemplates,
+		"outputs", successOutputs,
 		"duration", renderDuration)
 
 	return successTemplates, nil
@@ -407,6 +634,12 @@ func runFetchAndRender(configPath string, debugMode bool) error {
 		return err
 	}
 
+	unlock, err := lockCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	slog.Info("starting planet (run: fetch + render + post)",
 		"version", version,
 		"feeds", len(cfg.Feeds))
@@ -463,6 +696,12 @@ func runFetch(configPath string, debugMode bool) error {
 		return err
 	}
 
+	unlock, err := lockCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	slog.Info("starting planet (fetch only)",
 		"version", version,
 		"feeds", len(cfg.Feeds))
@@ -504,6 +743,12 @@ func runRender(configPath string, debugMode bool) error {
 		return err
 	}
 
+	unlock, err := lockCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	slog.Info("starting planet (render only)",
 		"version", version,
 		"templates", len(cfg.Planet.TemplateFiles))
@@ -533,6 +778,12 @@ func runPost(configPath string, debugMode bool) error {
 		return err
 	}
 
+	unlock, err := lockCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	slog.Info("starting planet (post to Twitter only)",
 		"version", version)
 
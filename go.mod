@@ -6,6 +6,8 @@ require (
 	github.com/go-ini/ini v1.67.0
 	github.com/michimani/gotwi v0.18.1
 	github.com/mmcdole/gofeed v1.3.0
+	go.etcd.io/bbolt v1.5.0
+	golang.org/x/net v0.58.0
 )
 
 require (
@@ -15,6 +17,6 @@ require (
 	github.com/mmcdole/goxpp v1.1.1-0.20240225020742-a0c311522b23 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
-	golang.org/x/net v0.4.0 // indirect
-	golang.org/x/text v0.5.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.41.0 // indirect
 )
@@ -0,0 +1,49 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "feed.json")
+
+	if err := WriteFile(path, []byte("first"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := WriteFile(path, []byte("second"), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "second" {
+		t.Errorf("content = %q, want %q", data, "second")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// No temporary files are left behind
+	files, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("directory has %d files, want 1", len(files))
+	}
+
+	// Writing into a missing directory fails
+	if err := WriteFile(filepath.Join(tmpDir, "missing", "feed.json"), []byte("x"), 0644); err == nil {
+		t.Error("WriteFile() into a missing directory succeeded")
+	}
+}
@@ -0,0 +1,359 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// BoltFile is the database file of the bolt backend in the cache directory
+const BoltFile = "cache.db"
+
+// boltOpenTimeout is how long to wait for another process holding the database
+const boltOpenTimeout = 10 * time.Second
+
+// Buckets of the bolt store:
+//
+//	feeds:   feed URL -> JSON metadata and health
+//	entries: one nested bucket per feed URL, position -> JSON entry
+//	by_date: effective date + position + feed URL -> nil
+//	by_id:   entry ID + 0x00 + feed URL + 0x00 + position -> position
+var (
+	bucketFeeds   = []byte("feeds")
+	bucketEntries = []byte("entries")
+	bucketByDate  = []byte("by_date")
+	bucketByID    = []byte("by_id")
+)
+
+// boltStore keeps all feeds in a single bbolt database, with entries indexed
+// by feed, date and ID
+type boltStore struct {
+	db *bbolt.DB
+}
+
+// boltHeader is the record stored in the feeds bucket
+type boltHeader struct {
+	FeedURL  string   `json:"feed_url"`
+	Metadata Metadata `json:"metadata"`
+	Health   Health   `json:"health"`
+}
+
+func openBoltStore(directory string) (*boltStore, error) {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, fmt.Errorf("create cache directory: %w", err)
+	}
+
+	path := filepath.Join(directory, BoltFile)
+	db, err := bbolt.Open(path, 0644, &bbolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		if errors.Is(err, bbolt.ErrTimeout) {
+			return nil, fmt.Errorf("open cache database %s: locked by another process", path)
+		}
+		return nil, fmt.Errorf("open cache database %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{bucketFeeds, bucketEntries, bucketByDate, bucketByID} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create cache buckets: %w", err)
+	}
+
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) Load(feedURL string) (*CachedFeed, error) {
+	var cached *CachedFeed
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		header, err := loadBoltHeader(tx, feedURL)
+		if err != nil || header == nil {
+			return err
+		}
+
+		entries, err := loadBoltEntries(tx, feedURL)
+		if err != nil {
+			return err
+		}
+
+		cached = &CachedFeed{
+			FeedURL:  header.FeedURL,
+			Metadata: header.Metadata,
+			Health:   header.Health,
+			Entries:  entries,
+		}
+		return nil
+	})
+	return cached, err
+}
+
+func (s *boltStore) LoadHeader(feedURL string) (*CachedFeed, error) {
+	var cached *CachedFeed
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		header, err := loadBoltHeader(tx, feedURL)
+		if err != nil || header == nil {
+			return err
+		}
+		cached = &CachedFeed{FeedURL: header.FeedURL, Metadata: header.Metadata, Health: header.Health}
+		return nil
+	})
+	return cached, err
+}
+
+func (s *boltStore) Save(feedURL string, feed *CachedFeed) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		if err := deleteBoltEntries(tx, feedURL); err != nil {
+			return err
+		}
+
+		bucket, err := tx.Bucket(bucketEntries).CreateBucket([]byte(feedURL))
+		if err != nil {
+			return fmt.Errorf("create entries bucket: %w", err)
+		}
+		byDate := tx.Bucket(bucketByDate)
+		byID := tx.Bucket(bucketByID)
+
+		for i, entry := range feed.Entries {
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return fmt.Errorf("marshal entry: %w", err)
+			}
+			pos := positionKey(i)
+			if err := bucket.Put(pos, data); err != nil {
+				return err
+			}
+			if err := byDate.Put(dateIndexKey(entry, pos, feedURL), nil); err != nil {
+				return err
+			}
+			if entry.ID != "" {
+				if err := byID.Put(idIndexKey(entry.ID, feedURL, pos), pos); err != nil {
+					return err
+				}
+			}
+		}
+
+		return putBoltHeader(tx, feedURL, feed)
+	})
+}
+
+func (s *boltStore) SaveHeader(feedURL string, feed *CachedFeed) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return putBoltHeader(tx, feedURL, feed)
+	})
+}
+
+func (s *boltStore) Delete(feedURL string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		if err := deleteBoltEntries(tx, feedURL); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketFeeds).Delete([]byte(feedURL))
+	})
+}
+
+func (s *boltStore) FeedURLs() ([]string, error) {
+	var urls []string
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketFeeds).ForEach(func(k, _ []byte) error {
+			urls = append(urls, string(k))
+			return nil
+		})
+	})
+	return urls, err
+}
+
+func (s *boltStore) Entries(feedURLs []string) ([]Entry, error) {
+	var wanted map[string]bool
+	if feedURLs != nil {
+		wanted = make(map[string]bool, len(feedURLs))
+		for _, feedURL := range feedURLs {
+			wanted[feedURL] = true
+		}
+	}
+
+	var entries []Entry
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		feeds := tx.Bucket(bucketEntries)
+		cursor := tx.Bucket(bucketByDate).Cursor()
+
+		// Walk the date index backwards for newest first
+		for k, _ := cursor.Last(); k != nil; k, _ = cursor.Prev() {
+			if len(k) < 16 {
+				continue
+			}
+			pos, feedURL := k[12:16], k[16:]
+			if wanted != nil && !wanted[string(feedURL)] {
+				continue
+			}
+
+			bucket := feeds.Bucket(feedURL)
+			if bucket == nil {
+				continue
+			}
+			entry, err := decodeEntry(bucket.Get(pos))
+			if err != nil {
+				return err
+			}
+			if entry != nil {
+				entries = append(entries, *entry)
+			}
+		}
+		return nil
+	})
+	return entries, err
+}
+
+func (s *boltStore) FindByID(id string) ([]Entry, error) {
+	var found []Entry
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		feeds := tx.Bucket(bucketEntries)
+		prefix := append([]byte(id), 0)
+		cursor := tx.Bucket(bucketByID).Cursor()
+
+		for k, pos := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, pos = cursor.Next() {
+			feedURL := k[len(prefix) : len(k)-len(pos)-1]
+			bucket := feeds.Bucket(feedURL)
+			if bucket == nil {
+				continue
+			}
+			entry, err := decodeEntry(bucket.Get(pos))
+			if err != nil {
+				return err
+			}
+			if entry != nil {
+				found = append(found, *entry)
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
+
+// loadBoltHeader returns a feed's header record, or nil if it is not cached
+func loadBoltHeader(tx *bbolt.Tx, feedURL string) (*boltHeader, error) {
+	data := tx.Bucket(bucketFeeds).Get([]byte(feedURL))
+	if data == nil {
+		return nil, nil
+	}
+
+	var header boltHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("unmarshal feed header: %w", err)
+	}
+	return &header, nil
+}
+
+// putBoltHeader stores a feed's metadata and health
+func putBoltHeader(tx *bbolt.Tx, feedURL string, feed *CachedFeed) error {
+	data, err := json.Marshal(boltHeader{FeedURL: feedURL, Metadata: feed.Metadata, Health: feed.Health})
+	if err != nil {
+		return fmt.Errorf("marshal feed header: %w", err)
+	}
+	return tx.Bucket(bucketFeeds).Put([]byte(feedURL), data)
+}
+
+// loadBoltEntries returns a feed's entries in their stored order
+func loadBoltEntries(tx *bbolt.Tx, feedURL string) ([]Entry, error) {
+	bucket := tx.Bucket(bucketEntries).Bucket([]byte(feedURL))
+	if bucket == nil {
+		return nil, nil
+	}
+
+	var entries []Entry
+	err := bucket.ForEach(func(_, v []byte) error {
+		entry, err := decodeEntry(v)
+		if err != nil || entry == nil {
+			return err
+		}
+		entries = append(entries, *entry)
+		return nil
+	})
+	return entries, err
+}
+
+// deleteBoltEntries removes a feed's entries and their index records
+func deleteBoltEntries(tx *bbolt.Tx, feedURL string) error {
+	feeds := tx.Bucket(bucketEntries)
+	bucket := feeds.Bucket([]byte(feedURL))
+	if bucket == nil {
+		return nil
+	}
+
+	byDate := tx.Bucket(bucketByDate)
+	byID := tx.Bucket(bucketByID)
+	err := bucket.ForEach(func(pos, v []byte) error {
+		entry, err := decodeEntry(v)
+		if err != nil || entry == nil {
+			return err
+		}
+		if err := byDate.Delete(dateIndexKey(*entry, pos, feedURL)); err != nil {
+			return err
+		}
+		if entry.ID != "" {
+			return byID.Delete(idIndexKey(entry.ID, feedURL, pos))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("delete index records: %w", err)
+	}
+
+	return feeds.DeleteBucket([]byte(feedURL))
+}
+
+// decodeEntry unmarshals a stored entry (nil data yields a nil entry)
+func decodeEntry(data []byte) (*Entry, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("unmarshal entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// positionKey encodes an entry's position within its feed
+func positionKey(i int) []byte {
+	key := make([]byte, 4)
+	binary.BigEndian.PutUint32(key, uint32(i))
+	return key
+}
+
+// dateIndexKey returns the by_date key of an entry: its effective date as
+// sortable seconds and nanoseconds, its position and the feed URL
+func dateIndexKey(entry Entry, pos []byte, feedURL string) []byte {
+	date := effectiveDate(entry)
+	key := make([]byte, 12, 16+len(feedURL))
+	binary.BigEndian.PutUint64(key, uint64(date.Unix())^(1<<63))
+	binary.BigEndian.PutUint32(key[8:], uint32(date.Nanosecond()))
+	key = append(key, pos...)
+	return append(key, feedURL...)
+}
+
+// idIndexKey returns the by_id key of an entry. The position keeps entries
+// with the same ID in one feed apart.
+func idIndexKey(id, feedURL string, pos []byte) []byte {
+	key := make([]byte, 0, len(id)+len(feedURL)+2+len(pos))
+	key = append(key, id...)
+	key = append(key, 0)
+	key = append(key, feedURL...)
+	key = append(key, 0)
+	return append(key, pos...)
+}
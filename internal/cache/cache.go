@@ -1,13 +1,17 @@
 package cache
 
 import (
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
+
+	"github.com/alexey-ott/planet-go/internal/atomicfile"
 )
 
 // Entry represents a cached feed entry
@@ -19,6 +23,7 @@ type Entry struct {
 	AuthorEmail  string    `json:"author_email"`
 	Date         time.Time `json:"date"`
 	ID           string    `json:"id"`
+	Categories   []string  `json:"categories,omitempty"`
 	ChannelName  string    `json:"channel_name"`
 	ChannelLink  string    `json:"channel_link"`
 	ChannelTitle string    `json:"channel_title"`
@@ -34,6 +39,14 @@ type Entry struct {
 	ChannelID          string    `json:"channel_id,omitempty"`
 	ChannelUpdated     time.Time `json:"channel_updated,omitempty"`
 	ChannelRights      string    `json:"channel_rights,omitempty"`
+
+	// FirstSeen is when the entry was first stored in the cache
+	FirstSeen time.Time `json:"first_seen,omitempty"`
+
+	// Backlog marks entries that were already old when the feed was added
+	// (beyond new_feed_items). They are kept so they are not mistaken for new
+	// entries later, but LoadAll does not return them.
+	Backlog bool `json:"backlog,omitempty"`
 }
 
 // Metadata holds HTTP caching information
@@ -41,141 +54,311 @@ type Metadata struct {
 	LastFetched  time.Time `json:"last_fetched"`
 	ETag         string    `json:"etag"`
 	LastModified string    `json:"last_modified"`
+
+	// Subscription status, applied to the config by "planet feeds fix"
+	MovedTo string `json:"moved_to,omitempty"` // Target of a permanent redirect (301/308)
+	Gone    bool   `json:"gone,omitempty"`     // Feed returned 410 Gone
 }
 
 // CachedFeed contains entries and metadata
 type CachedFeed struct {
+	FeedURL  string   `json:"feed_url,omitempty"`
 	Metadata Metadata `json:"metadata"`
+	Health   Health   `json:"health"`
 	Entries  []Entry  `json:"entries"`
 }
 
-// Cache manages file-based caching
+// Retention limits how many entries are kept in a feed's archive.
+// Zero values mean no limit.
+type Retention struct {
+	MaxEntries int           // Maximum number of entries kept per feed
+	MaxAge     time.Duration // Entries older than this are expunged
+}
+
+// Cache manages the feed cache on top of a storage backend
 type Cache struct {
 	directory string
+	retention Retention
+	store     Store
 }
 
-// New creates a new cache manager
+// New creates a new cache manager using the JSON backend
 func New(directory string) *Cache {
-	return &Cache{directory: directory}
+	return &Cache{directory: directory, store: newJSONStore(directory)}
 }
 
-// SaveEntries saves feed entries to cache
-func (c *Cache) SaveEntries(feedURL string, entries []Entry) error {
-	if err := os.MkdirAll(c.directory, 0755); err != nil {
-		return fmt.Errorf("create cache directory: %w", err)
+// Open creates a cache manager using the given backend (BackendJSON or
+// BackendBolt, empty means JSON). The cache must be closed after use.
+func Open(directory, backend string) (*Cache, error) {
+	store, err := openStore(directory, backend)
+	if err != nil {
+		return nil, err
 	}
+	return &Cache{directory: directory, store: store}, nil
+}
 
-	cached := CachedFeed{
-		Metadata: Metadata{
-			LastFetched: time.Now(),
-		},
-		Entries: entries,
-	}
+// Close releases the storage backend
+func (c *Cache) Close() error {
+	return c.store.Close()
+}
+
+// SetRetention sets the default retention used by SaveEntries
+func (c *Cache) SetRetention(retention Retention) {
+	c.retention = retention
+}
+
+// Retention returns the default retention used by SaveEntries
+func (c *Cache) Retention() Retention {
+	return c.retention
+}
 
-	path := c.cachePath(feedURL)
-	data, err := json.MarshalIndent(cached, "", "  ")
+// SaveEntries merges feed entries into the cache using the default retention
+func (c *Cache) SaveEntries(feedURL string, entries []Entry) error {
+	return c.MergeEntries(feedURL, entries, c.retention)
+}
+
+// MergeEntries merges freshly fetched entries into the feed's cached archive.
+// Entries are matched by ID: known entries keep their first-seen time but take
+// the updated content, new entries are added, and entries that dropped out of
+// the upstream feed are kept until the retention limits expunge them.
+func (c *Cache) MergeEntries(feedURL string, entries []Entry, retention Retention) error {
+	// Load existing data. Only a feed without an archive starts a fresh one:
+	// saving over an unreadable archive would lose its entries.
+	cached, err := c.store.Load(feedURL)
 	if err != nil {
-		return fmt.Errorf("marshal entries: %w", err)
+		return fmt.Errorf("load cache: %w", err)
+	}
+	if cached == nil {
+		cached = &CachedFeed{}
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
-		return fmt.Errorf("write cache file: %w", err)
+	now := time.Now()
+	cached.Metadata.LastFetched = now
+	cached.Entries = mergeEntries(cached.Entries, entries, retention, now)
+	for _, entry := range cached.Entries {
+		if entry.FirstSeen.Equal(now) {
+			cached.Health.LastNewEntry = now
+			break
+		}
 	}
 
-	return nil
+	return c.store.Save(feedURL, cached)
 }
 
 // LoadEntries loads feed entries from cache
 func (c *Cache) LoadEntries(feedURL string) ([]Entry, error) {
-	path := c.cachePath(feedURL)
+	cached, err := c.store.Load(feedURL)
+	if err != nil || cached == nil {
+		return nil, err // No cache is not an error
+	}
+	return cached.Entries, nil
+}
 
-	data, err := os.ReadFile(path)
+// SaveMetadata saves HTTP caching metadata
+func (c *Cache) SaveMetadata(feedURL string, meta Metadata) error {
+	header, err := c.store.LoadHeader(feedURL)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil // No cache, not an error
-		}
-		return nil, fmt.Errorf("read cache file: %w", err)
+		return fmt.Errorf("load cache: %w", err)
 	}
-
-	var cached CachedFeed
-	if err := json.Unmarshal(data, &cached); err != nil {
-		return nil, fmt.Errorf("unmarshal cache: %w", err)
+	if header == nil {
+		header = &CachedFeed{}
 	}
+	header.Metadata = meta
+	return c.store.SaveHeader(feedURL, header)
+}
 
-	return cached.Entries, nil
+// LoadMetadata loads HTTP caching metadata
+func (c *Cache) LoadMetadata(feedURL string) (*Metadata, error) {
+	header, err := c.store.LoadHeader(feedURL)
+	if err != nil || header == nil {
+		return nil, err
+	}
+	return &header.Metadata, nil
 }
 
-// SaveMetadata saves HTTP caching metadata
-func (c *Cache) SaveMetadata(feedURL string, meta Metadata) error {
-	if err := os.MkdirAll(c.directory, 0755); err != nil {
-		return fmt.Errorf("create cache directory: %w", err)
+// Move moves a feed's cached archive to a new feed URL, e.g. after the feed
+// was permanently redirected. Entries are re-attributed to the new URL and
+// merged into an existing archive for it, if any. The subscription status
+// is cleared.
+func (c *Cache) Move(oldURL, newURL string) error {
+	cached, err := c.store.Load(oldURL)
+	if err != nil {
+		return err
+	}
+	if cached == nil {
+		return nil // Nothing cached yet
 	}
 
-	path := c.cachePath(feedURL)
+	for i := range cached.Entries {
+		if cached.Entries[i].ChannelURL == oldURL {
+			cached.Entries[i].ChannelURL = newURL
+		}
+	}
 
-	// Load existing data
-	var cached CachedFeed
-	if data, err := os.ReadFile(path); err == nil {
-		json.Unmarshal(data, &cached)
+	// Merge with an archive already fetched from the new URL
+	if existing, err := c.store.Load(newURL); err == nil && existing != nil {
+		cached.Metadata = existing.Metadata
+		cached.Health = existing.Health
+		cached.Entries = mergeEntries(cached.Entries, existing.Entries, Retention{}, time.Now())
 	}
+	cached.Metadata.MovedTo = ""
+	cached.Metadata.Gone = false
 
-	cached.Metadata = meta
+	if err := c.store.Save(newURL, cached); err != nil {
+		return err
+	}
+
+	if oldURL != newURL {
+		if err := c.store.Delete(oldURL); err != nil {
+			return err
+		}
+	}
 
-	data, err := json.MarshalIndent(cached, "", "  ")
+	return nil
+}
+
+// LoadAll loads the cached entries of the given feeds, newest first, except
+// backlog entries. Archives of other feeds (e.g. feeds removed from the
+// config) are ignored. A nil feedURLs loads every cached feed.
+func (c *Cache) LoadAll(feedURLs []string) ([]Entry, error) {
+	entries, err := c.store.Entries(feedURLs)
 	if err != nil {
-		return fmt.Errorf("marshal metadata: %w", err)
+		return nil, fmt.Errorf("load cached entries: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
-		return fmt.Errorf("write cache file: %w", err)
+	var allEntries []Entry
+	for _, entry := range entries {
+		if !entry.Backlog {
+			allEntries = append(allEntries, entry)
+		}
 	}
 
-	return nil
+	return allEntries, nil
 }
 
-// LoadMetadata loads HTTP caching metadata
-func (c *Cache) LoadMetadata(feedURL string) (*Metadata, error) {
-	path := c.cachePath(feedURL)
+// FindByID returns the cached entries with the given ID, from any feed
+func (c *Cache) FindByID(id string) ([]Entry, error) {
+	return c.store.FindByID(id)
+}
+
+// FeedURLs returns the URLs of all cached feeds, sorted, including feeds
+// that are no longer configured
+func (c *Cache) FeedURLs() ([]string, error) {
+	return c.store.FeedURLs()
+}
 
-	data, err := os.ReadFile(path)
+// Load returns a feed's whole archive, or nil if the feed is not cached
+func (c *Cache) Load(feedURL string) (*CachedFeed, error) {
+	return c.store.Load(feedURL)
+}
+
+// Purge removes a feed's archive and raw file, so the next fetch treats it
+// as a newly added feed. It returns false if the feed was not cached.
+func (c *Cache) Purge(feedURL string) (bool, error) {
+	header, err := c.store.LoadHeader(feedURL)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("read cache file: %w", err)
+		return false, err
+	}
+	if header == nil {
+		return false, nil
 	}
 
-	var cached CachedFeed
-	if err := json.Unmarshal(data, &cached); err != nil {
-		return nil, fmt.Errorf("unmarshal cache: %w", err)
+	if err := c.store.Delete(feedURL); err != nil {
+		return false, err
 	}
 
-	return &cached.Metadata, nil
+	raw := filepath.Join(c.directory, cacheFileName(feedURL)+".xml")
+	if err := os.Remove(raw); err != nil && !os.IsNotExist(err) {
+		return true, fmt.Errorf("remove raw file: %w", err)
+	}
+	return true, nil
 }
 
-// LoadAll loads all cached entries from all feeds
-func (c *Cache) LoadAll() ([]Entry, error) {
-	entries, err := filepath.Glob(filepath.Join(c.directory, "*.json"))
-	if err != nil {
-		return nil, fmt.Errorf("glob cache files: %w", err)
+// mergeEntries merges fresh entries into existing ones and applies retention.
+// Entries present in the fresh set are always kept, since they would reappear
+// on the next fetch anyway. The result is sorted newest first.
+func mergeEntries(existing, fresh []Entry, retention Retention, now time.Time) []Entry {
+	previous := make(map[string]Entry, len(existing))
+	for _, entry := range existing {
+		previous[entryKey(entry)] = entry
 	}
 
-	var allEntries []Entry
-	for _, path := range entries {
-		data, err := os.ReadFile(path)
-		if err != nil {
-			continue // Skip unreadable files
+	merged := make([]Entry, 0, len(existing)+len(fresh))
+	current := make(map[string]bool, len(fresh))
+	for _, entry := range fresh {
+		key := entryKey(entry)
+		if current[key] {
+			continue // Duplicate item within the same feed
+		}
+		current[key] = true
+
+		if old, ok := previous[key]; ok {
+			entry.FirstSeen = old.FirstSeen
+			entry.Backlog = old.Backlog
+			// Keep the known date if the updated item lost its date metadata
+			if entry.Date.IsZero() {
+				entry.Date = old.Date
+			}
 		}
+		if entry.FirstSeen.IsZero() {
+			entry.FirstSeen = now
+		}
+		merged = append(merged, entry)
+	}
 
-		var cached CachedFeed
-		if err := json.Unmarshal(data, &cached); err != nil {
-			continue // Skip invalid files
+	// Carry over archived entries that are no longer in the upstream feed
+	var archived []Entry
+	for _, entry := range existing {
+		if current[entryKey(entry)] {
+			continue
+		}
+		if retention.MaxAge > 0 && effectiveDate(entry).Before(now.Add(-retention.MaxAge)) {
+			continue
 		}
+		archived = append(archived, entry)
+	}
+	sortNewestFirst(archived)
 
-		allEntries = append(allEntries, cached.Entries...)
+	if retention.MaxEntries > 0 {
+		room := retention.MaxEntries - len(merged)
+		if room < 0 {
+			room = 0
+		}
+		if len(archived) > room {
+			archived = archived[:room]
+		}
 	}
 
-	return allEntries, nil
+	merged = append(merged, archived...)
+	sortNewestFirst(merged)
+
+	return merged
+}
+
+// entryKey returns the key used to match an entry across fetches
+func entryKey(entry Entry) string {
+	if entry.ID != "" {
+		return entry.ID
+	}
+	if entry.Link != "" {
+		return entry.Link
+	}
+	return entry.Title
+}
+
+// effectiveDate returns the entry date, falling back to when it was first seen
+func effectiveDate(entry Entry) time.Time {
+	if entry.Date.IsZero() {
+		return entry.FirstSeen
+	}
+	return entry.Date
+}
+
+// sortNewestFirst sorts entries by effective date, newest first
+func sortNewestFirst(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return effectiveDate(entries[i]).After(effectiveDate(entries[j]))
+	})
 }
 
 // sanitizeURL converts a URL into a safe filename
@@ -206,10 +389,20 @@ func sanitizeURL(url string) string {
 	return url
 }
 
-// cachePath returns the file path for a feed URL
-func (c *Cache) cachePath(feedURL string) string {
-	filename := sanitizeURL(feedURL) + ".json"
-	return filepath.Join(c.directory, filename)
+// fileNamePrefixLen is the max length of the readable part of cache file names
+const fileNamePrefixLen = 64
+
+// cacheFileName returns the base name of a feed's cache files: a readable
+// prefix of the sanitized URL and a hash of the full URL, so that URLs that
+// differ only in their scheme or after a long common prefix do not collide.
+// Example: https://go.dev/blog/feed.atom -> go.dev-blog-feed.atom-cfa61ab3ee17909e
+func cacheFileName(feedURL string) string {
+	name := sanitizeURL(feedURL)
+	if len(name) > fileNamePrefixLen {
+		name = strings.TrimSuffix(name[:fileNamePrefixLen], "-")
+	}
+	sum := sha256.Sum256([]byte(feedURL))
+	return name + "-" + hex.EncodeToString(sum[:8])
 }
 
 // SaveRaw saves the raw fetched feed body to disk with a .xml extension.
@@ -220,10 +413,10 @@ func (c *Cache) SaveRaw(feedURL string, data []byte) error {
 		return fmt.Errorf("create cache directory: %w", err)
 	}
 
-	filename := sanitizeURL(feedURL) + ".xml"
+	filename := cacheFileName(feedURL) + ".xml"
 	path := filepath.Join(c.directory, filename)
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := atomicfile.WriteFile(path, data, 0644); err != nil {
 		return fmt.Errorf("write raw cache file: %w", err)
 	}
 
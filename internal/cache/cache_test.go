@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"os"
 	"testing"
 	"time"
 )
@@ -66,7 +67,7 @@ func TestCache_LoadAll(t *testing.T) {
 	}
 
 	// Load all entries
-	allEntries, err := cache.LoadAll()
+	allEntries, err := cache.LoadAll(nil)
 	if err != nil {
 		t.Fatalf("LoadAll() error = %v", err)
 	}
@@ -170,3 +171,220 @@ func TestSanitizeURL(t *testing.T) {
 		})
 	}
 }
+
+func TestCache_SaveEntriesMergesArchive(t *testing.T) {
+	tmpDir := t.TempDir()
+	cache := New(tmpDir)
+
+	feedURL := "https://example.com/feed.xml"
+	now := time.Now()
+
+	first := []Entry{
+		{Title: "Old Post", ID: "1", Date: now.Add(-2 * time.Hour), Content: "old"},
+		{Title: "Post", ID: "2", Date: now.Add(-1 * time.Hour), Content: "original"},
+	}
+	if err := cache.SaveEntries(feedURL, first); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := cache.LoadEntries(feedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstSeen := loaded[0].FirstSeen
+
+	// "Old Post" dropped out of the feed window, "Post" was edited
+	second := []Entry{
+		{Title: "New Post", ID: "3", Date: now, Content: "new"},
+		{Title: "Post", ID: "2", Date: now.Add(-1 * time.Hour), Content: "edited"},
+	}
+	if err := cache.SaveEntries(feedURL, second); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := cache.LoadEntries(feedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("len(entries) = %d, want 3", len(entries))
+	}
+
+	if entries[0].ID != "3" || entries[1].ID != "2" || entries[2].ID != "1" {
+		t.Errorf("entries not sorted newest first: %q, %q, %q", entries[0].ID, entries[1].ID, entries[2].ID)
+	}
+
+	if entries[1].Content != "edited" {
+		t.Errorf("entries[1].Content = %q, want %q", entries[1].Content, "edited")
+	}
+
+	if !entries[1].FirstSeen.Equal(firstSeen) {
+		t.Errorf("entries[1].FirstSeen = %v, want %v", entries[1].FirstSeen, firstSeen)
+	}
+}
+
+func TestMergeEntries_Retention(t *testing.T) {
+	now := time.Now()
+	existing := []Entry{
+		{ID: "a", Date: now.Add(-1 * time.Hour)},
+		{ID: "b", Date: now.Add(-48 * time.Hour)},
+		{ID: "c", Date: now.Add(-10 * 24 * time.Hour)},
+	}
+	fresh := []Entry{
+		{ID: "d", Date: now},
+		{ID: "old-but-current", Date: now.Add(-30 * 24 * time.Hour)},
+	}
+
+	tests := []struct {
+		name      string
+		retention Retention
+		want      []string
+	}{
+		{
+			name: "unlimited",
+			want: []string{"d", "a", "b", "c", "old-but-current"},
+		},
+		{
+			name:      "max entries",
+			retention: Retention{MaxEntries: 3},
+			want:      []string{"d", "a", "old-but-current"},
+		},
+		{
+			name:      "max entries below feed size keeps current items",
+			retention: Retention{MaxEntries: 1},
+			want:      []string{"d", "old-but-current"},
+		},
+		{
+			name:      "max age",
+			retention: Retention{MaxAge: 7 * 24 * time.Hour},
+			want:      []string{"d", "a", "b", "old-but-current"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := mergeEntries(existing, fresh, tt.retention, now)
+			if len(merged) != len(tt.want) {
+				t.Fatalf("len(merged) = %d, want %d", len(merged), len(tt.want))
+			}
+			for i, id := range tt.want {
+				if merged[i].ID != id {
+					t.Errorf("merged[%d].ID = %q, want %q", i, merged[i].ID, id)
+				}
+			}
+		})
+	}
+}
+
+func TestCache_UnreadableArchiveIsKept(t *testing.T) {
+	tmpDir := t.TempDir()
+	cache := New(tmpDir)
+	feedURL := "https://example.com/feed.xml"
+
+	if err := cache.SaveEntries(feedURL, []Entry{{ID: "1", Date: time.Now()}}); err != nil {
+		t.Fatal(err)
+	}
+	path := newJSONStore(tmpDir).path(feedURL)
+	corrupt := []byte(`{"entries": [{"id": "1"`)
+	if err := os.WriteFile(path, corrupt, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writes fail instead of replacing the archive with an empty one
+	if err := cache.SaveEntries(feedURL, []Entry{{ID: "2", Date: time.Now()}}); err == nil {
+		t.Error("SaveEntries() over an unreadable archive succeeded")
+	}
+	if err := cache.SaveMetadata(feedURL, Metadata{ETag: "x"}); err == nil {
+		t.Error("SaveMetadata() over an unreadable archive succeeded")
+	}
+	if err := cache.SaveHealth(feedURL, Health{ConsecutiveFailures: 1}); err == nil {
+		t.Error("SaveHealth() over an unreadable archive succeeded")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(corrupt) {
+		t.Errorf("archive was overwritten:\n%s", data)
+	}
+}
+
+func TestCache_Move(t *testing.T) {
+	tmpDir := t.TempDir()
+	cache := New(tmpDir)
+
+	oldURL := "https://old.example.com/feed.xml"
+	newURL := "https://new.example.com/feed.xml"
+	now := time.Now()
+
+	if err := cache.SaveEntries(oldURL, []Entry{{ID: "1", Date: now.Add(-time.Hour), ChannelURL: oldURL}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.SaveMetadata(oldURL, Metadata{MovedTo: newURL}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.SaveEntries(newURL, []Entry{{ID: "2", Date: now, ChannelURL: newURL}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.Move(oldURL, newURL); err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+
+	if _, err := os.Stat(newJSONStore(cache.directory).path(oldURL)); !os.IsNotExist(err) {
+		t.Errorf("old cache file still exists (err = %v)", err)
+	}
+
+	entries, err := cache.LoadEntries(newURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("len(entries) = %d, want 2", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.ChannelURL != newURL {
+			t.Errorf("entry %q ChannelURL = %q, want %q", entry.ID, entry.ChannelURL, newURL)
+		}
+	}
+
+	meta, err := cache.LoadMetadata(newURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.MovedTo != "" {
+		t.Errorf("meta.MovedTo = %q, want empty", meta.MovedTo)
+	}
+}
+
+func TestCache_Purge(t *testing.T) {
+	cache := New(t.TempDir())
+	feedURL := "https://example.com/feed.xml"
+
+	if err := cache.SaveEntries(feedURL, []Entry{{ID: "1", ChannelURL: feedURL}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.SaveRaw(feedURL, []byte("<rss/>")); err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := cache.Purge(feedURL)
+	if err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if !purged {
+		t.Error("Purge() = false, want true")
+	}
+	if urls, _ := cache.FeedURLs(); len(urls) != 0 {
+		t.Errorf("FeedURLs() after Purge = %v, want none", urls)
+	}
+	if orphans, _ := cache.Orphans(nil); len(orphans) != 0 {
+		t.Errorf("raw file left behind: %+v", orphans)
+	}
+
+	if purged, err := cache.Purge(feedURL); err != nil || purged {
+		t.Errorf("second Purge() = %v, %v, want false, nil", purged, err)
+	}
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Orphan is cached data of a feed that is no longer configured
+type Orphan struct {
+	FeedURL string // Feed URL of an orphaned archive, empty for raw files
+	Path    string // File on disk, empty for archives in the bolt database
+	Entries int    // Number of archived entries
+}
+
+// Orphans returns the archives and raw .xml files in the cache that do not
+// belong to any of the given feeds. Other files in the cache directory, such
+// as the Twitter tracking file, are never reported.
+func (c *Cache) Orphans(feedURLs []string) ([]Orphan, error) {
+	configured := make(map[string]bool, len(feedURLs))
+	rawFiles := make(map[string]bool, len(feedURLs))
+	for _, feedURL := range feedURLs {
+		configured[feedURL] = true
+		rawFiles[cacheFileName(feedURL)+".xml"] = true
+	}
+
+	urls, err := c.store.FeedURLs()
+	if err != nil {
+		return nil, fmt.Errorf("list cached feeds: %w", err)
+	}
+
+	files, isJSON := c.store.(*jsonStore)
+	var orphans []Orphan
+	for _, feedURL := range urls {
+		if configured[feedURL] {
+			continue
+		}
+
+		orphan := Orphan{FeedURL: feedURL}
+		if isJSON {
+			orphan.Path = files.path(feedURL)
+		}
+		if cached, err := c.store.Load(feedURL); err == nil && cached != nil {
+			orphan.Entries = len(cached.Entries)
+		}
+		orphans = append(orphans, orphan)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(c.directory, "*.xml"))
+	if err != nil {
+		return nil, fmt.Errorf("glob raw files: %w", err)
+	}
+	for _, path := range paths {
+		if !rawFiles[filepath.Base(path)] {
+			orphans = append(orphans, Orphan{Path: path})
+		}
+	}
+
+	// Archives first, then raw files
+	sort.SliceStable(orphans, func(i, j int) bool {
+		a, b := orphans[i], orphans[j]
+		if (a.FeedURL == "") != (b.FeedURL == "") {
+			return a.FeedURL != ""
+		}
+		if a.FeedURL != b.FeedURL {
+			return a.FeedURL < b.FeedURL
+		}
+		return a.Path < b.Path
+	})
+
+	return orphans, nil
+}
+
+// RemoveOrphan deletes an orphaned archive or raw file
+func (c *Cache) RemoveOrphan(orphan Orphan) error {
+	if orphan.FeedURL != "" {
+		return c.store.Delete(orphan.FeedURL)
+	}
+	if err := os.Remove(orphan.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove raw file: %w", err)
+	}
+	return nil
+}
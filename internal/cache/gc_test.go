@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCache_Orphans(t *testing.T) {
+	for _, backend := range []string{BackendJSON, BackendBolt} {
+		t.Run(backend, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			cache, err := Open(tmpDir, backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cache.Close()
+
+			kept := "https://example.com/kept.xml"
+			removed := "https://example.com/removed.xml"
+			for _, feedURL := range []string{kept, removed} {
+				if err := cache.SaveEntries(feedURL, []Entry{{ID: feedURL, ChannelURL: feedURL}}); err != nil {
+					t.Fatal(err)
+				}
+				if err := cache.SaveRaw(feedURL, []byte("<rss/>")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Other files in the cache directory are left alone
+			tracking := filepath.Join(tmpDir, "twitter_posted.json")
+			if err := os.WriteFile(tracking, []byte(`{"version":1,"articles":{}}`), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			configured := []string{kept}
+			if entries, _ := cache.LoadAll(configured); len(entries) != 1 || entries[0].ID != kept {
+				t.Errorf("LoadAll(configured) = %+v, want only the configured feed", entries)
+			}
+
+			orphans, err := cache.Orphans(configured)
+			if err != nil {
+				t.Fatalf("Orphans() error = %v", err)
+			}
+			if len(orphans) != 2 {
+				t.Fatalf("Orphans() = %+v, want archive and raw file", orphans)
+			}
+			if orphans[0].FeedURL != removed || orphans[0].Entries != 1 {
+				t.Errorf("orphans[0] = %+v, want archive of %s", orphans[0], removed)
+			}
+			if orphans[1].Path != filepath.Join(tmpDir, cacheFileName(removed)+".xml") {
+				t.Errorf("orphans[1].Path = %q, want raw file of %s", orphans[1].Path, removed)
+			}
+
+			for _, orphan := range orphans {
+				if err := cache.RemoveOrphan(orphan); err != nil {
+					t.Fatalf("RemoveOrphan() error = %v", err)
+				}
+			}
+			if orphans, _ := cache.Orphans(configured); len(orphans) != 0 {
+				t.Errorf("Orphans() after removal = %+v, want none", orphans)
+			}
+			if entries, _ := cache.LoadEntries(kept); len(entries) != 1 {
+				t.Errorf("configured feed lost its entries")
+			}
+			if _, err := os.Stat(tracking); err != nil {
+				t.Errorf("tracking file removed: %v", err)
+			}
+		})
+	}
+}
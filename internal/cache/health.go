@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// Health tracks how reliably a feed can be fetched
+type Health struct {
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastSuccess         time.Time `json:"last_success,omitzero"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastErrorAt         time.Time `json:"last_error_at,omitzero"`
+	LastNewEntry        time.Time `json:"last_new_entry,omitzero"` // Last time a fetch added an entry
+	AvgLatencyMS        float64   `json:"avg_latency_ms"`          // Moving average of fetch latency
+	Fetches             int       `json:"fetches"`                 // Number of fetches measured
+	SkipUntil           time.Time `json:"skip_until,omitzero"`     // Feed is not fetched before this time
+}
+
+// LoadHealth loads a feed's health record. A feed that is not cached has a
+// zero record.
+func (c *Cache) LoadHealth(feedURL string) (Health, error) {
+	header, err := c.store.LoadHeader(feedURL)
+	if err != nil || header == nil {
+		return Health{}, err
+	}
+	return header.Health, nil
+}
+
+// SaveHealth saves a feed's health record, keeping its entries and metadata
+func (c *Cache) SaveHealth(feedURL string, health Health) error {
+	header, err := c.store.LoadHeader(feedURL)
+	if err != nil {
+		return fmt.Errorf("load cache: %w", err)
+	}
+	if header == nil {
+		header = &CachedFeed{}
+	}
+	header.Health = health
+	return c.store.SaveHeader(feedURL, header)
+}
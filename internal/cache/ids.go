@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alexey-ott/planet-go/internal/atomicfile"
+)
+
+// idsMigratedMarker marks a cache directory whose entries have stable IDs
+const idsMigratedMarker = ".stable-ids"
+
+// StableID returns a deterministic entry ID: the item's GUID if it has one,
+// otherwise its link, otherwise a hash of the feed URL, title and date.
+// date should be the item's own date, not a fallback such as the channel's
+// updated time, so that the ID does not change between fetches.
+func StableID(feedURL, guid, link, title string, date time.Time) string {
+	if guid = strings.TrimSpace(guid); guid != "" {
+		return guid
+	}
+	if link = strings.TrimSpace(link); link != "" {
+		return link
+	}
+
+	dateStr := ""
+	if !date.IsZero() {
+		dateStr = date.UTC().Format(time.RFC3339)
+	}
+	sum := sha1.Sum([]byte(feedURL + "\n" + strings.TrimSpace(title) + "\n" + dateStr))
+	return "urn:sha1:" + hex.EncodeToString(sum[:])
+}
+
+// MigrateEntryIDs gives cached entries without an ID a stable ID (see
+// StableID). It runs once per cache directory and returns the number of
+// entries that were re-keyed.
+func (c *Cache) MigrateEntryIDs() (int, error) {
+	marker := filepath.Join(c.directory, idsMigratedMarker)
+	if _, err := os.Stat(marker); err == nil {
+		return 0, nil
+	}
+
+	urls, err := c.store.FeedURLs()
+	if err != nil {
+		return 0, fmt.Errorf("list cached feeds: %w", err)
+	}
+
+	migrated := 0
+	for _, feedURL := range urls {
+		cached, err := c.store.Load(feedURL)
+		if err != nil || cached == nil {
+			continue // Skip unreadable archives
+		}
+
+		changed := 0
+		for i, entry := range cached.Entries {
+			if entry.ID != "" {
+				continue
+			}
+			// Older fetchers stored the channel's updated time as the date of
+			// undated items, which the fetcher no longer hashes
+			date := entry.Date
+			if date.Equal(entry.ChannelUpdated) {
+				date = time.Time{}
+			}
+			channelURL := entry.ChannelURL
+			if channelURL == "" {
+				channelURL = feedURL
+			}
+			cached.Entries[i].ID = StableID(channelURL, "", entry.Link, entry.Title, date)
+			changed++
+		}
+		if changed == 0 {
+			continue
+		}
+
+		if err := c.store.Save(feedURL, cached); err != nil {
+			return migrated, err
+		}
+		migrated += changed
+	}
+
+	if err := os.MkdirAll(c.directory, 0755); err != nil {
+		return migrated, fmt.Errorf("create cache directory: %w", err)
+	}
+	if err := atomicfile.WriteFile(marker, []byte("1\n"), 0644); err != nil {
+		return migrated, fmt.Errorf("write migration marker: %w", err)
+	}
+
+	return migrated, nil
+}
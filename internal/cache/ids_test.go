@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStableID(t *testing.T) {
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	feedURL := "https://example.com/feed"
+
+	if got := StableID(feedURL, " guid-1 ", "https://example.com/1", "Title", date); got != "guid-1" {
+		t.Errorf("GUID: StableID() = %q, want %q", got, "guid-1")
+	}
+	if got := StableID(feedURL, "", "https://example.com/1", "Title", date); got != "https://example.com/1" {
+		t.Errorf("link: StableID() = %q, want %q", got, "https://example.com/1")
+	}
+
+	hashed := StableID(feedURL, "", "", "Title", date)
+	if !strings.HasPrefix(hashed, "urn:sha1:") {
+		t.Errorf("hash: StableID() = %q, want urn:sha1: prefix", hashed)
+	}
+	if again := StableID(feedURL, "", "", "Title", date.In(time.FixedZone("X", 3600))); again != hashed {
+		t.Errorf("hash is not stable across time zones: %q != %q", again, hashed)
+	}
+	for _, other := range []string{
+		StableID("https://other.example.com/feed", "", "", "Title", date),
+		StableID(feedURL, "", "", "Other title", date),
+		StableID(feedURL, "", "", "Title", date.Add(time.Hour)),
+	} {
+		if other == hashed {
+			t.Errorf("different entries share the ID %q", hashed)
+		}
+	}
+}
+
+func TestCache_MigrateEntryIDs(t *testing.T) {
+	tmpDir := t.TempDir()
+	cache := New(tmpDir)
+	feedURL := "https://example.com/feed"
+
+	entries := []Entry{
+		{ID: "guid", Title: "Has GUID"},
+		{Link: "https://example.com/2", Title: "Has link", ChannelURL: feedURL},
+		{Title: "Nothing", ChannelURL: feedURL},
+	}
+	// Write the legacy file directly, SaveEntries would merge entries by key
+	writeEntries(t, cache, feedURL, entries)
+
+	migrated, err := cache.MigrateEntryIDs()
+	if err != nil {
+		t.Fatalf("MigrateEntryIDs() error = %v", err)
+	}
+	if migrated != 2 {
+		t.Errorf("migrated = %d, want 2", migrated)
+	}
+
+	loaded, err := cache.LoadEntries(feedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"guid", "https://example.com/2", StableID(feedURL, "", "", "Nothing", time.Time{})}
+	for i, entry := range loaded {
+		if entry.ID != want[i] {
+			t.Errorf("entries[%d].ID = %q, want %q", i, entry.ID, want[i])
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, idsMigratedMarker)); err != nil {
+		t.Errorf("migration marker not written: %v", err)
+	}
+
+	// The migration only runs once
+	writeEntries(t, cache, feedURL, []Entry{{Title: "Later"}})
+	if migrated, _ := cache.MigrateEntryIDs(); migrated != 0 {
+		t.Errorf("second run migrated = %d, want 0", migrated)
+	}
+}
+
+// writeEntries replaces a feed's cached entries without merging
+func writeEntries(t *testing.T, cache *Cache, feedURL string, entries []Entry) {
+	t.Helper()
+	data, err := json.MarshalIndent(CachedFeed{Entries: entries}, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(newJSONStore(cache.directory).path(feedURL), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,344 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/alexey-ott/planet-go/internal/atomicfile"
+)
+
+// IndexFile maps feed URLs to cache files in the JSON backend
+const IndexFile = "index.json"
+
+// indexVersion is the current index file format version
+const indexVersion = 1
+
+// jsonStore keeps one pretty-printed JSON file per feed (see cacheFileName)
+// and an index of the feeds it holds
+type jsonStore struct {
+	directory string
+
+	mu    sync.Mutex
+	index map[string]string // Feed URL -> file name, nil until loaded
+}
+
+// jsonIndex is the content of the index file
+type jsonIndex struct {
+	Version int               `json:"version"`
+	Feeds   map[string]string `json:"feeds"`
+}
+
+func newJSONStore(directory string) *jsonStore {
+	return &jsonStore{directory: directory}
+}
+
+// path returns the file path for a feed URL
+func (s *jsonStore) path(feedURL string) string {
+	return filepath.Join(s.directory, cacheFileName(feedURL)+".json")
+}
+
+// read unmarshals a cache file into v. It returns false if the file does
+// not exist.
+func (s *jsonStore) read(path string, v any) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("read cache file: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("unmarshal cache: %w", err)
+	}
+	return true, nil
+}
+
+// loadIndex returns the index, loading it on first use. A missing or invalid
+// index is rebuilt from the cache files, which also migrates files from the
+// old naming scheme. The caller must hold s.mu.
+func (s *jsonStore) loadIndex() (map[string]string, error) {
+	if s.index != nil {
+		return s.index, nil
+	}
+
+	var index jsonIndex
+	if found, err := s.read(filepath.Join(s.directory, IndexFile), &index); err == nil && found && index.Feeds != nil {
+		s.index = index.Feeds
+		return s.index, nil
+	}
+
+	feeds, err := s.rebuildIndex()
+	if err != nil {
+		return nil, err
+	}
+	s.index = feeds
+	if len(feeds) > 0 {
+		if err := s.saveIndex(); err != nil {
+			return nil, err
+		}
+	}
+	return s.index, nil
+}
+
+// rebuildIndex scans the cache files for feed archives and renames files
+// that do not follow the current naming scheme
+func (s *jsonStore) rebuildIndex() (map[string]string, error) {
+	paths, err := filepath.Glob(filepath.Join(s.directory, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("glob cache files: %w", err)
+	}
+
+	feeds := make(map[string]string)
+	for _, path := range paths {
+		if filepath.Base(path) == IndexFile {
+			continue
+		}
+
+		var cached CachedFeed
+		if found, err := s.read(path, &cached); err != nil || !found {
+			continue // Skip unreadable and invalid files
+		}
+		feedURL := cachedFeedURL(&cached)
+		if feedURL == "" {
+			continue // Not a feed archive, e.g. the Twitter tracking file
+		}
+
+		target := s.path(feedURL)
+		if path != target {
+			if _, err := os.Stat(target); err == nil {
+				continue // Already migrated, keep the current file
+			}
+			if err := os.Rename(path, target); err != nil {
+				return nil, fmt.Errorf("rename cache file: %w", err)
+			}
+		}
+		feeds[feedURL] = filepath.Base(target)
+	}
+
+	return feeds, nil
+}
+
+// saveIndex writes the index file. The caller must hold s.mu.
+func (s *jsonStore) saveIndex() error {
+	if err := os.MkdirAll(s.directory, 0755); err != nil {
+		return fmt.Errorf("create cache directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(jsonIndex{Version: indexVersion, Feeds: s.index}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal cache index: %w", err)
+	}
+
+	if err := atomicfile.WriteFile(filepath.Join(s.directory, IndexFile), data, 0644); err != nil {
+		return fmt.Errorf("write cache index: %w", err)
+	}
+	return nil
+}
+
+// indexedPaths returns the files of the given feeds (all indexed feeds if
+// feedURLs is nil)
+func (s *jsonStore) indexedPaths(feedURLs []string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, err := s.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	if feedURLs == nil {
+		for _, name := range index {
+			paths = append(paths, filepath.Join(s.directory, name))
+		}
+		sort.Strings(paths)
+		return paths, nil
+	}
+	for _, feedURL := range feedURLs {
+		if name, ok := index[feedURL]; ok {
+			paths = append(paths, filepath.Join(s.directory, name))
+		}
+	}
+	return paths, nil
+}
+
+// ready loads the index so that files from the old naming scheme are
+// migrated before they are read
+func (s *jsonStore) ready() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.loadIndex()
+	return err
+}
+
+func (s *jsonStore) Load(feedURL string) (*CachedFeed, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+
+	var cached CachedFeed
+	found, err := s.read(s.path(feedURL), &cached)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &cached, nil
+}
+
+func (s *jsonStore) LoadHeader(feedURL string) (*CachedFeed, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+
+	// Decode without the entries so they are not allocated
+	var header struct {
+		FeedURL  string   `json:"feed_url"`
+		Metadata Metadata `json:"metadata"`
+		Health   Health   `json:"health"`
+	}
+	found, err := s.read(s.path(feedURL), &header)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &CachedFeed{FeedURL: header.FeedURL, Metadata: header.Metadata, Health: header.Health}, nil
+}
+
+func (s *jsonStore) Save(feedURL string, feed *CachedFeed) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Load the index first so that the first save migrates older files
+	index, err := s.loadIndex()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.directory, 0755); err != nil {
+		return fmt.Errorf("create cache directory: %w", err)
+	}
+
+	cached := *feed
+	cached.FeedURL = feedURL
+
+	data, err := json.MarshalIndent(cached, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal cache: %w", err)
+	}
+
+	path := s.path(feedURL)
+	if err := atomicfile.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("write cache file: %w", err)
+	}
+
+	if _, ok := index[feedURL]; !ok {
+		index[feedURL] = filepath.Base(path)
+		return s.saveIndex()
+	}
+	return nil
+}
+
+func (s *jsonStore) SaveHeader(feedURL string, feed *CachedFeed) error {
+	// The entries share the file, so it is rewritten as a whole
+	cached, err := s.Load(feedURL)
+	if err != nil {
+		return err
+	}
+	if cached == nil {
+		cached = &CachedFeed{} // A missing file starts a fresh archive
+	}
+	cached.Metadata = feed.Metadata
+	cached.Health = feed.Health
+	return s.Save(feedURL, cached)
+}
+
+func (s *jsonStore) Delete(feedURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, err := s.loadIndex()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.path(feedURL)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove cache file: %w", err)
+	}
+
+	if _, ok := index[feedURL]; ok {
+		delete(index, feedURL)
+		return s.saveIndex()
+	}
+	return nil
+}
+
+func (s *jsonStore) FeedURLs() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, err := s.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, len(index))
+	for feedURL := range index {
+		urls = append(urls, feedURL)
+	}
+	sort.Strings(urls)
+	return urls, nil
+}
+
+func (s *jsonStore) Entries(feedURLs []string) ([]Entry, error) {
+	paths, err := s.indexedPaths(feedURLs)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, path := range paths {
+		var cached CachedFeed
+		if found, err := s.read(path, &cached); err != nil || !found {
+			continue // Skip unreadable and invalid files
+		}
+		entries = append(entries, cached.Entries...)
+	}
+	sortNewestFirst(entries)
+	return entries, nil
+}
+
+func (s *jsonStore) FindByID(id string) ([]Entry, error) {
+	// No index, scan every file
+	entries, err := s.Entries(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []Entry
+	for _, entry := range entries {
+		if entry.ID == id {
+			found = append(found, entry)
+		}
+	}
+	return found, nil
+}
+
+func (s *jsonStore) Close() error {
+	return nil
+}
+
+// cachedFeedURL returns the URL of a cached feed. Files written before the
+// URL was stored fall back to the feed URL recorded on the entries.
+func cachedFeedURL(cached *CachedFeed) string {
+	if cached.FeedURL != "" {
+		return cached.FeedURL
+	}
+	for _, entry := range cached.Entries {
+		if entry.ChannelURL != "" {
+			return entry.ChannelURL
+		}
+	}
+	return ""
+}
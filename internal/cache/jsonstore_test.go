@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJSONStore_NoCollisions(t *testing.T) {
+	cache := New(t.TempDir())
+
+	prefix := "https://example.com/" + strings.Repeat("a", 250)
+	urls := []string{
+		"http://example.com/feed.xml",
+		"https://example.com/feed.xml",
+		prefix + "/one",
+		prefix + "/two",
+	}
+	for _, feedURL := range urls {
+		if err := cache.SaveEntries(feedURL, []Entry{{ID: feedURL, ChannelURL: feedURL}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, feedURL := range urls {
+		entries, err := cache.LoadEntries(feedURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].ID != feedURL {
+			t.Errorf("LoadEntries(%q) = %+v, want its own entry", feedURL, entries)
+		}
+	}
+
+	if name := cacheFileName(prefix + "/one"); len(name) > fileNamePrefixLen+17 {
+		t.Errorf("cacheFileName() = %q is too long", name)
+	}
+}
+
+func TestJSONStore_MigratesLegacyFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	feedURL := "https://example.com/feed.xml"
+
+	// A file named by the old scheme, written before feed_url was stored
+	legacy := filepath.Join(tmpDir, sanitizeURL(feedURL)+".json")
+	data, err := json.Marshal(CachedFeed{
+		Metadata: Metadata{ETag: `"v1"`},
+		Entries:  []Entry{{ID: "1", ChannelURL: feedURL}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tracking := filepath.Join(tmpDir, "twitter_posted.json")
+	if err := os.WriteFile(tracking, []byte(`{"version":1,"articles":{}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := New(tmpDir)
+	meta, err := cache.LoadMetadata(feedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta == nil || meta.ETag != `"v1"` {
+		t.Errorf("LoadMetadata() = %+v, want the migrated metadata", meta)
+	}
+
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("legacy file still exists (err = %v)", err)
+	}
+	if _, err := os.Stat(newJSONStore(tmpDir).path(feedURL)); err != nil {
+		t.Errorf("migrated file missing: %v", err)
+	}
+	if _, err := os.Stat(tracking); err != nil {
+		t.Errorf("tracking file moved: %v", err)
+	}
+
+	// The index lists the feed for a new cache instance
+	var index jsonIndex
+	data, err = os.ReadFile(filepath.Join(tmpDir, IndexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatal(err)
+	}
+	if index.Feeds[feedURL] != filepath.Base(newJSONStore(tmpDir).path(feedURL)) {
+		t.Errorf("index.Feeds = %v, want %s", index.Feeds, feedURL)
+	}
+	if urls, _ := New(tmpDir).store.FeedURLs(); len(urls) != 1 || urls[0] != feedURL {
+		t.Errorf("FeedURLs() = %v, want [%s]", urls, feedURL)
+	}
+}
@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LockFile is the advisory lock file in the cache directory
+const LockFile = "planet.lock"
+
+// lockPollInterval is how often a held lock is retried while waiting
+const lockPollInterval = 500 * time.Millisecond
+
+// lockWriteGrace is how long an empty or unreadable lock file is assumed to
+// be in the middle of being written by its owner
+const lockWriteGrace = time.Minute
+
+// ErrLocked is returned by LockDirectory when another process holds the lock
+var ErrLocked = errors.New("cache directory is locked by another planet process")
+
+// ErrStaleLock is returned by LockDirectory when the lock file was left
+// behind by a process that is no longer running
+var ErrStaleLock = errors.New("stale cache lock")
+
+// Lock is an advisory lock on a cache directory
+type Lock struct {
+	path  string
+	owner []byte // Content of the lock file written by this process
+}
+
+// lockOwner is the content of the lock file
+type lockOwner struct {
+	PID   int       `json:"pid"`
+	Host  string    `json:"host"`
+	Since time.Time `json:"since"`
+}
+
+// LockDirectory takes the advisory lock on a cache directory so that
+// overlapping runs do not write the cache at the same time. If another process
+// holds the lock it waits up to wait (0 means fail right away) and then fails
+// with ErrLocked. A lock left behind by a process that is no longer running is
+// not removed automatically, LockDirectory fails with ErrStaleLock instead.
+func LockDirectory(directory string, wait time.Duration) (*Lock, error) {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, fmt.Errorf("create cache directory: %w", err)
+	}
+
+	path := filepath.Join(directory, LockFile)
+	host, _ := os.Hostname()
+	owner, err := json.Marshal(lockOwner{PID: os.Getpid(), Host: host, Since: time.Now()})
+	if err != nil {
+		return nil, fmt.Errorf("marshal lock owner: %w", err)
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = file.Write(append(owner, '\n'))
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("write lock file: %w", err)
+			}
+			return &Lock{path: path, owner: owner}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("create lock file: %w", err)
+		}
+
+		held, err := checkLock(path, host)
+		if err != nil {
+			return nil, err
+		}
+		if held == nil {
+			continue // Released in the meantime
+		}
+		if !time.Now().Before(deadline) {
+			return nil, fmt.Errorf("%w: pid %d on %s since %s (lock file %s)",
+				ErrLocked, held.PID, held.Host, held.Since.Format(time.RFC3339), path)
+		}
+		time.Sleep(min(lockPollInterval, time.Until(deadline)))
+	}
+}
+
+// checkLock returns the owner of an existing lock file, nil if the file is
+// gone, or an ErrStaleLock error if its owner is no longer running
+func checkLock(path, host string) (*lockOwner, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("stat lock file: %w", err)
+	}
+
+	var owner lockOwner
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &owner)
+	}
+	if err != nil || owner.PID == 0 {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		if time.Since(info.ModTime()) < lockWriteGrace {
+			// Probably still being written
+			return &lockOwner{Host: "unknown host", Since: info.ModTime()}, nil
+		}
+		return nil, fmt.Errorf("%w: lock file %s is unreadable; remove it if no other planet process is running", ErrStaleLock, path)
+	}
+
+	// Liveness can only be checked for processes on this host
+	if owner.Host == host && !processRunning(owner.PID) {
+		return nil, fmt.Errorf("%w: lock file %s was left by pid %d (since %s), which is no longer running; remove it if no other planet process is running",
+			ErrStaleLock, path, owner.PID, owner.Since.Format(time.RFC3339))
+	}
+
+	return &owner, nil
+}
+
+// Unlock releases the lock. The lock file is only removed if it still holds
+// this lock, e.g. not after an operator removed it as stale and another run
+// took the lock.
+func (l *Lock) Unlock() error {
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("read lock file: %w", err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(data), l.owner) {
+		return fmt.Errorf("lock file %s was taken over by another process, not removed", l.path)
+	}
+
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove lock file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package cache
+
+import "os"
+
+// processRunning reports whether a process with the given PID exists
+func processRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockDirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	lock, err := LockDirectory(tmpDir, 0)
+	if err != nil {
+		t.Fatalf("LockDirectory() error = %v", err)
+	}
+
+	// A second run fails right away
+	if _, err := LockDirectory(tmpDir, 0); !errors.Is(err, ErrLocked) {
+		t.Errorf("second LockDirectory() error = %v, want ErrLocked", err)
+	}
+
+	// ... or waits for the first one to finish
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		lock.Unlock()
+	}()
+	second, err := LockDirectory(tmpDir, 5*time.Second)
+	if err != nil {
+		t.Fatalf("waiting LockDirectory() error = %v", err)
+	}
+	if err := second.Unlock(); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, LockFile)); !os.IsNotExist(err) {
+		t.Errorf("lock file still exists after Unlock (err = %v)", err)
+	}
+}
+
+func TestLock_UnlockTakenOver(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	first, err := LockDirectory(tmpDir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The lock file is removed as stale and another run takes the lock
+	if err := os.Remove(filepath.Join(tmpDir, LockFile)); err != nil {
+		t.Fatal(err)
+	}
+	second, err := LockDirectory(tmpDir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := first.Unlock(); err == nil {
+		t.Error("Unlock() of a taken over lock succeeded")
+	}
+	if _, err := LockDirectory(tmpDir, 0); !errors.Is(err, ErrLocked) {
+		t.Errorf("LockDirectory() error = %v, want the second lock kept", err)
+	}
+
+	if err := second.Unlock(); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	if err := second.Unlock(); err != nil {
+		t.Errorf("second Unlock() error = %v", err)
+	}
+}
+
+func TestLockDirectory_Stale(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, LockFile)
+	host, _ := os.Hostname()
+
+	// Left behind by a process that is no longer running
+	data, _ := json.Marshal(lockOwner{PID: 1<<31 - 1, Host: host, Since: time.Now().Add(-time.Hour)})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LockDirectory(tmpDir, time.Second); !errors.Is(err, ErrStaleLock) {
+		t.Errorf("LockDirectory() error = %v, want ErrStaleLock", err)
+	}
+
+	// Empty files are only stale once the owner had time to write them
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LockDirectory(tmpDir, 0); !errors.Is(err, ErrLocked) {
+		t.Errorf("fresh empty lock: LockDirectory() error = %v, want ErrLocked", err)
+	}
+	old := time.Now().Add(-2 * lockWriteGrace)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LockDirectory(tmpDir, 0); !errors.Is(err, ErrStaleLock) {
+		t.Errorf("old empty lock: LockDirectory() error = %v, want ErrStaleLock", err)
+	}
+
+	// The stale lock is not removed automatically
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("stale lock file removed: %v", err)
+	}
+}
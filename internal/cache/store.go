@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Cache storage backends
+const (
+	BackendJSON = "json" // One JSON file per feed
+	BackendBolt = "bolt" // Single-file embedded bbolt database
+)
+
+// Store persists cached feeds. Implementations must be safe for concurrent
+// use by multiple goroutines.
+type Store interface {
+	// Load returns a feed's archive, or nil if the feed is not cached
+	Load(feedURL string) (*CachedFeed, error)
+
+	// LoadHeader returns a feed's metadata and health without its entries,
+	// or nil if the feed is not cached
+	LoadHeader(feedURL string) (*CachedFeed, error)
+
+	// Save replaces a feed's archive
+	Save(feedURL string, feed *CachedFeed) error
+
+	// SaveHeader replaces a feed's metadata and health, keeping its entries
+	SaveHeader(feedURL string, feed *CachedFeed) error
+
+	// Delete removes a feed's archive
+	Delete(feedURL string) error
+
+	// FeedURLs returns the URLs of all cached feeds
+	FeedURLs() ([]string, error)
+
+	// Entries returns the entries of the given feeds (all cached feeds if
+	// feedURLs is nil), newest first
+	Entries(feedURLs []string) ([]Entry, error)
+
+	// FindByID returns the cached entries with the given ID, from any feed
+	FindByID(id string) ([]Entry, error)
+
+	// Close releases the store
+	Close() error
+}
+
+// openStore opens the store for a backend in a cache directory
+func openStore(directory, backend string) (Store, error) {
+	switch strings.ToLower(strings.TrimSpace(backend)) {
+	case "", BackendJSON:
+		return newJSONStore(directory), nil
+	case BackendBolt:
+		return openBoltStore(directory)
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q (want %q or %q)", backend, BackendJSON, BackendBolt)
+	}
+}
+
+// Migrate copies every feed archive from one backend to another in the same
+// cache directory and returns the number of feeds copied. The source is left
+// untouched.
+func Migrate(directory, from, to string) (int, error) {
+	src, err := openStore(directory, from)
+	if err != nil {
+		return 0, fmt.Errorf("open %s cache: %w", from, err)
+	}
+	defer src.Close()
+
+	dst, err := openStore(directory, to)
+	if err != nil {
+		return 0, fmt.Errorf("open %s cache: %w", to, err)
+	}
+	defer dst.Close()
+
+	urls, err := src.FeedURLs()
+	if err != nil {
+		return 0, fmt.Errorf("list cached feeds: %w", err)
+	}
+
+	copied := 0
+	for _, feedURL := range urls {
+		feed, err := src.Load(feedURL)
+		if err != nil {
+			return copied, fmt.Errorf("load %s: %w", feedURL, err)
+		}
+		if feed == nil {
+			continue
+		}
+		if err := dst.Save(feedURL, feed); err != nil {
+			return copied, fmt.Errorf("save %s: %w", feedURL, err)
+		}
+		copied++
+	}
+
+	return copied, nil
+}
@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStore_Backends(t *testing.T) {
+	for _, backend := range []string{BackendJSON, BackendBolt} {
+		t.Run(backend, func(t *testing.T) {
+			cache, err := Open(t.TempDir(), backend)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			defer cache.Close()
+
+			now := time.Now()
+			feedA := "https://a.example.com/feed"
+			feedB := "https://b.example.com/feed"
+
+			if err := cache.SaveEntries(feedA, []Entry{
+				{ID: "a1", Title: "A1", Date: now.Add(-2 * time.Hour), ChannelURL: feedA},
+				{ID: "shared", Title: "A2", Date: now, ChannelURL: feedA},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if err := cache.SaveEntries(feedB, []Entry{
+				{ID: "shared", Title: "B1", Date: now.Add(-time.Hour), ChannelURL: feedB},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if err := cache.SaveMetadata(feedA, Metadata{ETag: `"abc"`}); err != nil {
+				t.Fatal(err)
+			}
+			if err := cache.SaveHealth(feedA, Health{ConsecutiveFailures: 3}); err != nil {
+				t.Fatal(err)
+			}
+
+			// Saving the header keeps the entries
+			entries, err := cache.LoadEntries(feedA)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 2 {
+				t.Errorf("len(LoadEntries()) = %d, want 2", len(entries))
+			}
+			if meta, _ := cache.LoadMetadata(feedA); meta == nil || meta.ETag != `"abc"` {
+				t.Errorf("LoadMetadata() = %+v, want ETag \"abc\"", meta)
+			}
+			if health, _ := cache.LoadHealth(feedA); health.ConsecutiveFailures != 3 {
+				t.Errorf("LoadHealth().ConsecutiveFailures = %d, want 3", health.ConsecutiveFailures)
+			}
+			if meta, err := cache.LoadMetadata("https://missing.example.com/feed"); err != nil || meta != nil {
+				t.Errorf("LoadMetadata(missing) = %v, %v, want nil, nil", meta, err)
+			}
+
+			// Entries of all feeds, newest first
+			all, err := cache.LoadAll(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantTitles := []string{"A2", "B1", "A1"}
+			if len(all) != len(wantTitles) {
+				t.Fatalf("len(LoadAll()) = %d, want %d", len(all), len(wantTitles))
+			}
+			for i, title := range wantTitles {
+				if all[i].Title != title {
+					t.Errorf("LoadAll()[%d].Title = %q, want %q", i, all[i].Title, title)
+				}
+			}
+
+			found, err := cache.FindByID("shared")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(found) != 2 {
+				t.Errorf("len(FindByID(shared)) = %d, want 2", len(found))
+			}
+
+			// Replacing a feed's entries drops the old ones from the indexes
+			if err := cache.MergeEntries(feedA, []Entry{{ID: "a3", Title: "A3", Date: now, ChannelURL: feedA}}, Retention{MaxEntries: 1}); err != nil {
+				t.Fatal(err)
+			}
+			if found, _ := cache.FindByID("shared"); len(found) != 1 {
+				t.Errorf("len(FindByID(shared)) after merge = %d, want 1", len(found))
+			}
+			if all, _ := cache.LoadAll(nil); len(all) != 2 {
+				t.Errorf("len(LoadAll()) after merge = %d, want 2", len(all))
+			}
+
+			urls, err := cache.store.FeedURLs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(urls) != 2 {
+				t.Errorf("FeedURLs() = %v, want 2 feeds", urls)
+			}
+
+			if err := cache.store.Delete(feedB); err != nil {
+				t.Fatal(err)
+			}
+			if entries, _ := cache.LoadEntries(feedB); entries != nil {
+				t.Errorf("LoadEntries() after Delete = %v, want nil", entries)
+			}
+			if found, _ := cache.FindByID("shared"); len(found) != 0 {
+				t.Errorf("len(FindByID(shared)) after Delete = %d, want 0", len(found))
+			}
+		})
+	}
+}
+
+func TestStore_DuplicateIDs(t *testing.T) {
+	for _, backend := range []string{BackendJSON, BackendBolt} {
+		t.Run(backend, func(t *testing.T) {
+			cache, err := Open(t.TempDir(), backend)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			defer cache.Close()
+
+			// Feeds may reuse an ID, e.g. for an edited post
+			feedURL := "https://example.com/feed"
+			feed := &CachedFeed{FeedURL: feedURL, Entries: []Entry{
+				{ID: "1", Title: "First", ChannelURL: feedURL},
+				{ID: "1", Title: "Edited", ChannelURL: feedURL},
+			}}
+			if err := cache.store.Save(feedURL, feed); err != nil {
+				t.Fatal(err)
+			}
+			if found, _ := cache.FindByID("1"); len(found) != 2 {
+				t.Errorf("len(FindByID()) = %d, want 2", len(found))
+			}
+
+			// Replacing the entries drops both index records
+			feed.Entries = feed.Entries[1:]
+			if err := cache.store.Save(feedURL, feed); err != nil {
+				t.Fatal(err)
+			}
+			if found, _ := cache.FindByID("1"); len(found) != 1 || found[0].Title != "Edited" {
+				t.Errorf("FindByID() after Save = %+v, want the edited entry", found)
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	tmpDir := t.TempDir()
+	feedURL := "https://example.com/feed"
+
+	jsonCache := New(tmpDir)
+	if err := jsonCache.SaveEntries(feedURL, []Entry{{ID: "1", Title: "One", ChannelURL: feedURL}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := jsonCache.SaveMetadata(feedURL, Metadata{ETag: `"v1"`}); err != nil {
+		t.Fatal(err)
+	}
+
+	copied, err := Migrate(tmpDir, BackendJSON, BackendBolt)
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if copied != 1 {
+		t.Errorf("copied = %d, want 1", copied)
+	}
+
+	boltCache, err := Open(tmpDir, BackendBolt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer boltCache.Close()
+
+	entries, err := boltCache.LoadEntries(feedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Title != "One" {
+		t.Errorf("LoadEntries() = %+v, want the migrated entry", entries)
+	}
+	if meta, _ := boltCache.LoadMetadata(feedURL); meta == nil || meta.ETag != `"v1"` {
+		t.Errorf("LoadMetadata() = %+v, want ETag \"v1\"", meta)
+	}
+
+	if _, err := Open(tmpDir, "sqlite"); err == nil {
+		t.Error("Open() with an unknown backend succeeded")
+	}
+}
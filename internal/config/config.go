@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-ini/ini"
 )
@@ -16,6 +19,10 @@ type Config struct {
 	Templates map[string]TemplateConfig
 }
 
+// OutputNames are the built-in feed outputs that can be listed in outputs,
+// sorted (see renderer.OutputNames)
+var OutputNames = []string{"atom", "json", "opml", "rss"}
+
 // PlanetConfig holds global planet settings
 type PlanetConfig struct {
 	Name                string
@@ -26,19 +33,65 @@ type PlanetConfig struct {
 	OutputDir           string
 	LogLevel            string
 	FeedTimeout         int
-	NewFeedItems        int
+	NewFeedItems        int // Entries shown from a newly added feed (default: 10, 0 = all)
 	ItemsPerPage        int
 	DaysPerPage         int
+	MaxPages            int  // Max number of paginated pages per HTML template (default: 0 = unlimited)
+	Archives            bool // Write monthly archive pages for HTML templates (default: true)
+	ActivityThreshold   int  // Days without new entries before a feed is marked inactive (default: 0 = never)
 	DateFormat          string
 	NewDateFormat       string
 	Encoding            string
 	TemplateFiles       []string
 	Filter              string
 	Exclude             string
+	FilterMode          string // How feed-level filter/exclude combine with the global ones: "override", "and" or "or" (default: "override")
+	Dedupe              bool   // Drop the same article found in several feeds (default: true)
+	DedupeKeep          string // Which feed keeps a duplicate: "earliest", "priority" or "config_order" (default: "earliest")
 	PostToTwitter       bool
 	TwitterTrackingFile string
-	FetchMode           string // "parallel" or "sequential" (default: "parallel")
-	ParallelWorkers     int    // Number of parallel workers (default: 10)
+	FetchMode           string        // "parallel" or "sequential" (default: "parallel")
+	ParallelWorkers     int           // Number of parallel workers (default: 10)
+	MaxPerHost          int           // Max concurrent requests per host in parallel mode (default: 2, 0 = unlimited)
+	HostDelay           time.Duration // Min delay between requests to the same host (default: 1s)
+	FetchRetries        int           // Retries of transient fetch failures (default: 2)
+	RetryBaseDelay      time.Duration // Backoff before the first retry, doubled per retry (default: 2s)
+	RetryMaxDelay       time.Duration // Max backoff / Retry-After wait (default: 60s)
+	FailureThreshold    int           // Consecutive failures before a feed is skipped (default: 10, 0 = never)
+	FailureBackoff      time.Duration // How long a failing feed is skipped, doubled per failure (default: 1h)
+	FailureMaxBackoff   time.Duration // Max time a failing feed is skipped (default: 7 days)
+	CacheKeepEntries    int           // Max entries kept per feed in the cache (default: 0 = unlimited)
+	CacheKeepDays       int           // Expunge cached entries older than N days (default: 0 = never)
+	CacheBackend        string        // "json" or "bolt" (default: "json")
+	LockTimeout         time.Duration // How long to wait for another run holding the cache lock (default: 0 = exit)
+
+	// External filter programs (Venus-style), run on every entry in order
+	Filters       []string
+	FilterTimeout time.Duration // Max run time of a filter program per entry (default: 10s)
+
+	// Built-in content transformers, e.g. "absolute_urls lazy_images"
+	Transformers []string
+
+	// Length of the generated entry excerpts (0 = no limit)
+	ExcerptWords int // Words of text kept in an excerpt (default: 100)
+	ExcerptChars int // Characters of text kept in an excerpt (default: 0)
+
+	// Built-in feed outputs written besides the templates, e.g. "atom rss json opml"
+	Outputs []string
+
+	// HTML sanitization of entry content (default: enabled)
+	Sanitize                bool
+	SanitizeAllowTags       []string // Extra allowed elements, e.g. "iframe video"
+	SanitizeAllowAttributes []string // Extra allowed attributes, e.g. "class img:loading"
+}
+
+// FeedURLs returns the URLs of all configured feeds (never nil)
+func (c *Config) FeedURLs() []string {
+	urls := make([]string, 0, len(c.Feeds))
+	for _, feed := range c.Feeds {
+		urls = append(urls, feed.URL)
+	}
+	return urls
 }
 
 // FeedConfig represents a single feed subscription
@@ -72,9 +125,104 @@ func (f *FeedConfig) Exclude() string {
 	return ""
 }
 
+// FilterMode returns the feed-level filter_mode, or def if not set
+func (f *FeedConfig) FilterMode(def string) string {
+	if mode := strings.TrimSpace(f.Extra["filter_mode"]); mode != "" {
+		return mode
+	}
+	return def
+}
+
+// Filters returns the feed's external filter programs, run after the global ones
+func (f *FeedConfig) Filters() []string {
+	return strings.Fields(f.Extra["filters"])
+}
+
+// Transformers returns the feed's content transformers, or def if not set.
+// An empty value disables the global transformers for the feed.
+func (f *FeedConfig) Transformers(def []string) []string {
+	value, ok := f.Extra["transformers"]
+	if !ok {
+		return def
+	}
+	return strings.Fields(value)
+}
+
+// CacheKeepEntries returns the feed-level cache_keep_entries, or def if not set
+func (f *FeedConfig) CacheKeepEntries(def int) int {
+	return f.extraInt("cache_keep_entries", def)
+}
+
+// CacheKeepDays returns the feed-level cache_keep_days, or def if not set
+func (f *FeedConfig) CacheKeepDays(def int) int {
+	return f.extraInt("cache_keep_days", def)
+}
+
+// NewFeedItems returns the feed-level new_feed_items, or def if not set
+func (f *FeedConfig) NewFeedItems(def int) int {
+	return f.extraInt("new_feed_items", def)
+}
+
+// ActivityThreshold returns the feed-level activity_threshold (days), or def if not set
+func (f *FeedConfig) ActivityThreshold(def int) int {
+	return f.extraInt("activity_threshold", def)
+}
+
+// DedupePriority returns the feed-level dedupe_priority, or def if not set.
+// With dedupe_keep = priority, duplicates are attributed to the feed with the
+// highest priority.
+func (f *FeedConfig) DedupePriority(def int) int {
+	return f.extraInt("dedupe_priority", def)
+}
+
+// Sanitize returns the feed-level sanitize flag, or def if not set
+func (f *FeedConfig) Sanitize(def bool) bool {
+	value, ok := f.Extra["sanitize"]
+	if !ok {
+		return def
+	}
+	enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return def
+	}
+	return enabled
+}
+
+// SanitizeAllowTags returns extra elements allowed for this feed's content
+func (f *FeedConfig) SanitizeAllowTags() []string {
+	return strings.Fields(f.Extra["sanitize_allow_tags"])
+}
+
+// SanitizeAllowAttributes returns extra attributes allowed for this feed's content
+func (f *FeedConfig) SanitizeAllowAttributes() []string {
+	return strings.Fields(f.Extra["sanitize_allow_attributes"])
+}
+
+// extraInt parses an integer feed-level option, returning def if unset or invalid
+func (f *FeedConfig) extraInt(key string, def int) int {
+	value, ok := f.Extra[key]
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return def
+	}
+	return n
+}
+
 // TemplateConfig holds per-template settings
 type TemplateConfig struct {
-	DaysPerPage int
+	DaysPerPage       int
+	ActivityThreshold int // -1 if not set for this template
+
+	// Entry content shown by the template: "full" or "excerpt" (default: "full")
+	Content      string
+	ExcerptWords int // -1 if not set for this template
+	ExcerptChars int // -1 if not set for this template
+
+	// Template engine: "html", "xml" or "text" (default: by output extension)
+	Engine string
 }
 
 // Load reads and parses the config file
@@ -151,15 +299,53 @@ func parsePlanetSection(iniFile *ini.File, config *Config) error {
 		NewFeedItems:        section.Key("new_feed_items").MustInt(10),
 		ItemsPerPage:        section.Key("items_per_page").MustInt(15),
 		DaysPerPage:         section.Key("days_per_page").MustInt(0),
-		DateFormat:          strftimeToGoLayout(rawDate),
-		NewDateFormat:       strftimeToGoLayout(rawNewDate),
+		MaxPages:            section.Key("max_pages").MustInt(0),
+		Archives:            section.Key("archives").MustBool(true),
+		ActivityThreshold:   section.Key("activity_threshold").MustInt(0),
+		DateFormat:          StrftimeToGoLayout(rawDate),
+		NewDateFormat:       StrftimeToGoLayout(rawNewDate),
 		Encoding:            section.Key("encoding").MustString("utf-8"),
 		Filter:              section.Key("filter").String(),
 		Exclude:             section.Key("exclude").String(),
+		FilterMode:          section.Key("filter_mode").MustString("override"),
+		Dedupe:              section.Key("dedupe").MustBool(true),
+		DedupeKeep:          section.Key("dedupe_keep").MustString("earliest"),
 		PostToTwitter:       section.Key("post_to_twitter").MustBool(false),
 		TwitterTrackingFile: twitterTrackingFile,
 		FetchMode:           section.Key("fetch_mode").MustString("parallel"),
 		ParallelWorkers:     section.Key("parallel_workers").MustInt(10),
+		MaxPerHost:          section.Key("max_per_host").MustInt(2),
+		HostDelay:           seconds(section.Key("host_delay").MustFloat64(1)),
+		FetchRetries:        section.Key("fetch_retries").MustInt(2),
+		RetryBaseDelay:      seconds(section.Key("retry_base_delay").MustFloat64(2)),
+		RetryMaxDelay:       seconds(section.Key("retry_max_delay").MustFloat64(60)),
+		FailureThreshold:    section.Key("failure_threshold").MustInt(10),
+		FailureBackoff:      hours(section.Key("failure_backoff_hours").MustFloat64(1)),
+		FailureMaxBackoff:   hours(section.Key("failure_max_backoff_hours").MustFloat64(168)),
+		CacheKeepEntries:    section.Key("cache_keep_entries").MustInt(0),
+		CacheKeepDays:       section.Key("cache_keep_days").MustInt(0),
+		CacheBackend:        section.Key("cache_backend").MustString("json"),
+		LockTimeout:         seconds(section.Key("lock_timeout").MustFloat64(0)),
+
+		Filters:       strings.Fields(section.Key("filters").String()),
+		FilterTimeout: seconds(section.Key("filter_timeout").MustFloat64(10)),
+
+		Transformers: strings.Fields(section.Key("transformers").String()),
+
+		ExcerptWords: section.Key("excerpt_words").MustInt(100),
+		ExcerptChars: section.Key("excerpt_chars").MustInt(0),
+
+		Outputs: strings.Fields(strings.ToLower(section.Key("outputs").String())),
+
+		Sanitize:                section.Key("sanitize").MustBool(true),
+		SanitizeAllowTags:       strings.Fields(section.Key("sanitize_allow_tags").String()),
+		SanitizeAllowAttributes: strings.Fields(section.Key("sanitize_allow_attributes").String()),
+	}
+
+	for _, name := range config.Planet.Outputs {
+		if !slices.Contains(OutputNames, name) {
+			return fmt.Errorf("unknown output %q (want %s)", name, strings.Join(OutputNames, ", "))
+		}
 	}
 
 	// Parse template_files (space-separated) and resolve paths relative to CWD
@@ -179,6 +365,16 @@ func parsePlanetSection(iniFile *ini.File, config *Config) error {
 	return nil
 }
 
+// seconds converts a (possibly fractional) number of seconds to a duration
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
+
+// hours converts a (possibly fractional) number of hours to a duration
+func hours(value float64) time.Duration {
+	return time.Duration(value * float64(time.Hour))
+}
+
 func parseFeedSections(iniFile *ini.File, config *Config) error {
 	for _, section := range iniFile.Sections() {
 		name := section.Name()
@@ -241,7 +437,23 @@ func parseTemplateSections(iniFile *ini.File, config *Config) error {
 		}
 
 		templateConfig := TemplateConfig{
-			DaysPerPage: section.Key("days_per_page").MustInt(0),
+			DaysPerPage:       section.Key("days_per_page").MustInt(0),
+			ActivityThreshold: section.Key("activity_threshold").MustInt(-1),
+
+			Content:      section.Key("content").MustString("full"),
+			ExcerptWords: section.Key("excerpt_words").MustInt(-1),
+			ExcerptChars: section.Key("excerpt_chars").MustInt(-1),
+
+			Engine: strings.ToLower(section.Key("engine").String()),
+		}
+
+		if templateConfig.Content != "full" && templateConfig.Content != "excerpt" {
+			return fmt.Errorf("template %s: unknown content %q (want full or excerpt)", name, templateConfig.Content)
+		}
+		switch templateConfig.Engine {
+		case "", "html", "xml", "text":
+		default:
+			return fmt.Errorf("template %s: unknown engine %q (want html, xml or text)", name, templateConfig.Engine)
 		}
 
 		config.Templates[templateName] = templateConfig
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -82,3 +83,70 @@ name = Another Feed
 		t.Errorf("Feed[0].Extra[twitter] = %q, want %q", feed.Extra["twitter"], "exampleuser")
 	}
 }
+
+func TestLoad_CacheRetention(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.ini")
+
+	content := `[Planet]
+name = Test Planet
+cache_keep_entries = 50
+cache_keep_days = 365
+
+[https://example.com/feed.xml]
+name = Example Feed
+cache_keep_entries = 5
+
+[https://another.com/rss]
+name = Another Feed
+`
+
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Planet.CacheKeepEntries != 50 {
+		t.Errorf("Planet.CacheKeepEntries = %d, want 50", cfg.Planet.CacheKeepEntries)
+	}
+	if cfg.Planet.CacheKeepDays != 365 {
+		t.Errorf("Planet.CacheKeepDays = %d, want 365", cfg.Planet.CacheKeepDays)
+	}
+
+	if got := cfg.Feeds[0].CacheKeepEntries(cfg.Planet.CacheKeepEntries); got != 5 {
+		t.Errorf("Feeds[0].CacheKeepEntries() = %d, want 5", got)
+	}
+	if got := cfg.Feeds[1].CacheKeepEntries(cfg.Planet.CacheKeepEntries); got != 50 {
+		t.Errorf("Feeds[1].CacheKeepEntries() = %d, want 50", got)
+	}
+	if got := cfg.Feeds[0].CacheKeepDays(cfg.Planet.CacheKeepDays); got != 365 {
+		t.Errorf("Feeds[0].CacheKeepDays() = %d, want 365", got)
+	}
+}
+
+func TestLoad_Outputs(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.ini")
+
+	if err := os.WriteFile(configPath, []byte("[Planet]\noutputs = Atom rss\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if strings.Join(cfg.Planet.Outputs, " ") != "atom rss" {
+		t.Errorf("Planet.Outputs = %v, want [atom rss]", cfg.Planet.Outputs)
+	}
+
+	if err := os.WriteFile(configPath, []byte("[Planet]\noutputs = atomm\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(configPath); err == nil {
+		t.Error("Load() accepted an unknown output")
+	}
+}
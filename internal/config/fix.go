@@ -0,0 +1,120 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+)
+
+// FeedFix is a change to a feed subscription detected while fetching
+type FeedFix struct {
+	URL     string // Feed URL (section name) in the config
+	MovedTo string // New URL of a permanently redirected feed
+	Gone    bool   // Feed returned 410 Gone
+
+	// Set by FixFeeds
+	Applied bool   // Whether the config was changed
+	Action  string // What was done, for the report
+}
+
+// FixFeeds rewrites the raw INI config text: sections of moved feeds are
+// renamed to the new URL and sections of gone feeds are commented out.
+// Everything else, including comments and formatting, is kept as is.
+// A moved feed whose new URL is already subscribed is commented out instead.
+// It returns the new config text and the fixes with Applied/Action filled in.
+func FixFeeds(data []byte, fixes []FeedFix, note string) ([]byte, []FeedFix) {
+	lines := strings.SplitAfter(string(data), "\n")
+
+	// Index section headers by name
+	sections := make(map[string]int)
+	for i, line := range lines {
+		if name, ok := sectionName(line); ok {
+			sections[name] = i
+		}
+	}
+
+	results := make([]FeedFix, len(fixes))
+	for i, fix := range fixes {
+		start, ok := sections[fix.URL]
+		if !ok {
+			fix.Action = "section not found in config"
+			results[i] = fix
+			continue
+		}
+
+		switch {
+		case fix.Gone:
+			commentOutSection(lines, start, note+": feed is gone (410 Gone)")
+			fix.Action = "commented out (410 Gone)"
+		case fix.MovedTo != "":
+			if _, exists := sections[fix.MovedTo]; exists {
+				commentOutSection(lines, start, note+": feed moved to "+fix.MovedTo+", which is already subscribed")
+				fix.Action = "commented out (moved to an already subscribed URL)"
+			} else {
+				lines[start] = renameSection(lines[start], fix.MovedTo)
+				sections[fix.MovedTo] = start
+				fix.Action = "renamed to " + fix.MovedTo
+			}
+		default:
+			results[i] = fix
+			continue
+		}
+
+		delete(sections, fix.URL)
+		fix.Applied = true
+		results[i] = fix
+	}
+
+	var out bytes.Buffer
+	for _, line := range lines {
+		out.WriteString(line)
+	}
+	return out.Bytes(), results
+}
+
+// sectionName returns the name of an INI section header line
+func sectionName(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+		return "", false
+	}
+	return strings.TrimSpace(line[1 : len(line)-1]), true
+}
+
+// renameSection rewrites a section header line with a new name, keeping the
+// indentation and line ending
+func renameSection(line, name string) string {
+	trimmed := strings.TrimLeftFunc(line, unicode.IsSpace)
+	indent := line[:len(line)-len(trimmed)]
+	trimmed = strings.TrimRightFunc(trimmed, unicode.IsSpace)
+	ending := line[len(indent)+len(trimmed):]
+	return indent + "[" + name + "]" + ending
+}
+
+// commentOutSection comments out the section starting at line start (up to
+// the next section header) and puts a note above it
+func commentOutSection(lines []string, start int, note string) {
+	end := start + 1
+	for end < len(lines) {
+		if _, ok := sectionName(lines[end]); ok {
+			break
+		}
+		end++
+	}
+
+	// Leave trailing blank lines and comments of the next section alone
+	for end > start+1 {
+		trimmed := strings.TrimSpace(lines[end-1])
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, ";") {
+			break
+		}
+		end--
+	}
+
+	for i := start; i < end; i++ {
+		if strings.TrimSpace(lines[i]) != "" {
+			lines[i] = "# " + lines[i]
+		}
+	}
+	lines[start] = "# " + note + "\n" + lines[start]
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFixFeeds(t *testing.T) {
+	input := `[Planet]
+name = Test Planet
+
+# Moves to a new home
+[https://old.example.com/feed]
+name = Moved Blog
+
+[https://dead.example.com/feed]
+name = Dead Blog
+filter = go
+
+# Next feed
+[https://b.example.com/feed]
+name = B
+
+[https://dup.example.com/feed]
+name = Duplicate
+
+  [ http://spaced.example.com/feed ]
+name = Spaced
+`
+
+	fixes := []FeedFix{
+		{URL: "https://old.example.com/feed", MovedTo: "https://new.example.com/feed"},
+		{URL: "https://dead.example.com/feed", Gone: true},
+		{URL: "https://dup.example.com/feed", MovedTo: "https://b.example.com/feed"},
+		{URL: "https://missing.example.com/feed", Gone: true},
+		{URL: "http://spaced.example.com/feed", MovedTo: "https://spaced.example.com/feed"},
+	}
+
+	output, results := FixFeeds([]byte(input), fixes, "fixed")
+	got := string(output)
+
+	want := `[Planet]
+name = Test Planet
+
+# Moves to a new home
+[https://new.example.com/feed]
+name = Moved Blog
+
+# fixed: feed is gone (410 Gone)
+# [https://dead.example.com/feed]
+# name = Dead Blog
+# filter = go
+
+# Next feed
+[https://b.example.com/feed]
+name = B
+
+# fixed: feed moved to https://b.example.com/feed, which is already subscribed
+# [https://dup.example.com/feed]
+# name = Duplicate
+
+  [https://spaced.example.com/feed]
+name = Spaced
+`
+	if got != want {
+		t.Errorf("FixFeeds() output:\n%s\nwant:\n%s", got, want)
+	}
+
+	wantApplied := []bool{true, true, true, false, true}
+	for i, result := range results {
+		if result.Applied != wantApplied[i] {
+			t.Errorf("results[%d].Applied = %v, want %v (action %q)", i, result.Applied, wantApplied[i], result.Action)
+		}
+		if result.Action == "" {
+			t.Errorf("results[%d].Action is empty", i)
+		}
+	}
+
+	// The rewritten config must still parse, without the gone feeds
+	configPath := filepath.Join(t.TempDir(), "config.ini")
+	if err := os.WriteFile(configPath, output, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	var urls []string
+	for _, feed := range cfg.Feeds {
+		urls = append(urls, feed.URL)
+	}
+	if strings.Join(urls, " ") != "https://new.example.com/feed https://b.example.com/feed https://spaced.example.com/feed" {
+		t.Errorf("feeds = %v", urls)
+	}
+}
@@ -2,11 +2,11 @@ package config
 
 import "strings"
 
-// strftimeToGoLayout converts a subset of strftime-style directives to Go
+// StrftimeToGoLayout converts a subset of strftime-style directives to Go
 // time.Format layout strings. It intentionally supports the common tokens used
 // in the example `config.ini` (e.g. "%B %d, %Y %I:%M %p"). Tokens that are
 // not present are left untouched.
-func strftimeToGoLayout(s string) string {
+func StrftimeToGoLayout(s string) string {
 	// Replacement pairs: strftime -> Go layout
 	// Order matters for tokens where one is prefix of another.
 	r := strings.NewReplacer(
@@ -0,0 +1,222 @@
+// Package dedupe drops entries that several feeds carry for the same article,
+// e.g. a post that appears in an author's own feed and in an aggregate feed.
+package dedupe
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+// Rules for picking which feed keeps a duplicate article
+const (
+	KeepEarliest    = "earliest"     // The feed where the article was seen first
+	KeepPriority    = "priority"     // The feed with the highest dedupe_priority
+	KeepConfigOrder = "config_order" // The feed listed first in the config
+)
+
+// trackingParams are query parameters stripped from links before comparing
+// them. Parameters starting with "utm_" are stripped as well.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"igshid":  true,
+	"yclid":   true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+	"ref_src": true,
+}
+
+// IsTrackingParam reports whether a query parameter only serves to track
+// clicks, e.g. utm_source or fbclid
+func IsTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+	return trackingParams[key] || strings.HasPrefix(key, "utm_")
+}
+
+// CanonicalLink normalizes an entry link for comparison: the scheme and a
+// leading "www." are ignored, the host is lowercased, default ports,
+// fragments, trailing slashes and tracking parameters are dropped and the
+// remaining query parameters are sorted. Links that cannot be parsed are
+// returned trimmed.
+func CanonicalLink(link string) string {
+	link = strings.TrimSpace(link)
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return link
+	}
+
+	host := strings.ToLower(u.Hostname())
+	host = strings.TrimPrefix(host, "www.")
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	query := u.Query()
+	for key := range query {
+		if IsTrackingParam(key) {
+			query.Del(key)
+		}
+	}
+
+	path := strings.TrimSuffix(u.EscapedPath(), "/")
+	canonical := host + path
+	if len(query) > 0 {
+		canonical += "?" + query.Encode() // Encode sorts by key
+	}
+	return canonical
+}
+
+// Apply drops duplicate entries across feeds. Entries are duplicates if they
+// share a canonical link or a globally unique ID (a URL, tag: or urn: URI).
+// Other IDs, such as numeric GUIDs, are only unique within a feed and match
+// across feeds only together with the title. Of each group of duplicates
+// only the entry picked by the keep rule remains, at its position in entries.
+// Duplicates within a single feed are left alone. It returns the remaining
+// entries and the number of dropped ones.
+func Apply(entries []cache.Entry, feedConfigs []config.FeedConfig, keep string) ([]cache.Entry, int, error) {
+	better, err := keepRule(keep, feedConfigs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Group entries that share a key (union-find over entry indexes)
+	parent := make([]int, len(entries))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	owner := make(map[string]int)
+	for i, entry := range entries {
+		for _, key := range entryKeys(entry) {
+			j, seen := owner[key]
+			if !seen {
+				owner[key] = i
+				continue
+			}
+			if entries[j].ChannelURL == entry.ChannelURL {
+				continue // Same feed, not a cross-feed duplicate
+			}
+			if a, b := find(i), find(j); a != b {
+				parent[a] = b
+			}
+		}
+	}
+
+	// Pick the entry that keeps the attribution in every group
+	winner := make(map[int]int)
+	for i := range entries {
+		root := find(i)
+		if w, ok := winner[root]; !ok || better(entries[i], entries[w]) {
+			winner[root] = i
+		}
+	}
+
+	kept := make([]cache.Entry, 0, len(entries))
+	dropped := 0
+	for i, entry := range entries {
+		w := winner[find(i)]
+		if w != i && entries[w].ChannelURL != entry.ChannelURL {
+			dropped++
+			slog.Debug("dropped duplicate entry",
+				"title", entry.Title,
+				"feed", entry.ChannelName,
+				"kept_feed", entries[w].ChannelName)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+
+	return kept, dropped, nil
+}
+
+// entryKeys returns the keys under which an entry is compared with others
+func entryKeys(entry cache.Entry) []string {
+	var keys []string
+	if link := CanonicalLink(entry.Link); link != "" {
+		keys = append(keys, "link:"+link)
+	}
+
+	id := strings.TrimSpace(entry.ID)
+	u, err := url.Parse(id)
+	switch {
+	case id == "":
+	case err == nil && (u.Scheme == "http" || u.Scheme == "https"):
+		// IDs that are URLs (e.g. permalink GUIDs) also match links
+		keys = append(keys, "link:"+CanonicalLink(id))
+	case globalID(id):
+		keys = append(keys, "id:"+id)
+	default:
+		// Feeds pick IDs such as "42" on their own, so a shared one alone
+		// does not make the same article
+		if title := strings.ToLower(strings.TrimSpace(entry.Title)); title != "" {
+			keys = append(keys, "id:"+id+"\x00"+title)
+		}
+	}
+	return keys
+}
+
+// globalID reports whether an entry ID is a URI scheme meant to be unique
+// across feeds
+func globalID(id string) bool {
+	id = strings.ToLower(id)
+	return strings.HasPrefix(id, "tag:") || strings.HasPrefix(id, "urn:")
+}
+
+// keepRule returns a function reporting whether entry a should keep the
+// attribution over entry b
+func keepRule(keep string, feedConfigs []config.FeedConfig) (func(a, b cache.Entry) bool, error) {
+	order := make(map[string]int, len(feedConfigs))
+	priority := make(map[string]int, len(feedConfigs))
+	for i, feed := range feedConfigs {
+		order[feed.URL] = i
+		priority[feed.URL] = feed.DedupePriority(0)
+	}
+
+	// Seen first, then published first, then config order
+	earliest := func(a, b cache.Entry) bool {
+		if !a.FirstSeen.Equal(b.FirstSeen) {
+			return !a.FirstSeen.IsZero() && (b.FirstSeen.IsZero() || a.FirstSeen.Before(b.FirstSeen))
+		}
+		if !a.Date.Equal(b.Date) {
+			return !a.Date.IsZero() && (b.Date.IsZero() || a.Date.Before(b.Date))
+		}
+		return order[a.ChannelURL] < order[b.ChannelURL]
+	}
+
+	switch strings.ToLower(strings.TrimSpace(keep)) {
+	case "", KeepEarliest:
+		return earliest, nil
+	case KeepPriority:
+		return func(a, b cache.Entry) bool {
+			if priority[a.ChannelURL] != priority[b.ChannelURL] {
+				return priority[a.ChannelURL] > priority[b.ChannelURL]
+			}
+			return earliest(a, b)
+		}, nil
+	case KeepConfigOrder:
+		return func(a, b cache.Entry) bool {
+			if order[a.ChannelURL] != order[b.ChannelURL] {
+				return order[a.ChannelURL] < order[b.ChannelURL]
+			}
+			return earliest(a, b)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown dedupe_keep rule %q (want %s, %s or %s)", keep, KeepEarliest, KeepPriority, KeepConfigOrder)
+	}
+}
@@ -0,0 +1,125 @@
+package dedupe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+func TestCanonicalLink(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"https://example.com/post/", "example.com/post"},
+		{"http://www.Example.com/post", "example.com/post"},
+		{"https://example.com:443/post#comments", "example.com/post"},
+		{"https://example.com/post?utm_source=rss&utm_medium=feed", "example.com/post"},
+		{"https://example.com/post?b=2&fbclid=x&a=1", "example.com/post?a=1&b=2"},
+		{"https://example.com:8080/post", "example.com:8080/post"},
+		{"tag:example.com,2024:post-1", "tag:example.com,2024:post-1"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := CanonicalLink(tt.link); got != tt.want {
+			t.Errorf("CanonicalLink(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	personal := "https://alice.example.com/feed"
+	aggregate := "https://planet.example.com/feed"
+	feeds := []config.FeedConfig{
+		{URL: aggregate, Name: "Aggregate", Extra: map[string]string{}},
+		{URL: personal, Name: "Alice", Extra: map[string]string{"dedupe_priority": "10"}},
+	}
+
+	now := time.Now()
+	entries := []cache.Entry{
+		// Same article: the aggregate saw it an hour after Alice's feed
+		{Title: "Post", Link: "https://alice.example.com/post?utm_source=planet", ID: "agg-1", ChannelURL: aggregate, FirstSeen: now},
+		{Title: "Post", Link: "https://alice.example.com/post/", ID: "alice-1", ChannelURL: personal, FirstSeen: now.Add(-time.Hour)},
+		// Same ID, different links
+		{Title: "Other", Link: "https://planet.example.com/other", ID: "tag:x,2024:other", ChannelURL: aggregate, FirstSeen: now.Add(-2 * time.Hour)},
+		{Title: "Other", Link: "https://alice.example.com/other", ID: "tag:x,2024:other", ChannelURL: personal, FirstSeen: now},
+		// Unique
+		{Title: "Unique", Link: "https://planet.example.com/unique", ChannelURL: aggregate, FirstSeen: now},
+	}
+
+	tests := []struct {
+		keep string
+		want []string // Feed of each kept entry, in order
+	}{
+		{KeepEarliest, []string{personal, aggregate, aggregate}},
+		{KeepPriority, []string{personal, personal, aggregate}},
+		{KeepConfigOrder, []string{aggregate, aggregate, aggregate}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.keep, func(t *testing.T) {
+			kept, dropped, err := Apply(entries, feeds, tt.keep)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if dropped != 2 {
+				t.Errorf("dropped = %d, want 2", dropped)
+			}
+			if len(kept) != len(tt.want) {
+				t.Fatalf("len(kept) = %d, want %d", len(kept), len(tt.want))
+			}
+			for i, feed := range tt.want {
+				if kept[i].ChannelURL != feed {
+					t.Errorf("kept[%d] (%s) from %s, want %s", i, kept[i].Title, kept[i].ChannelURL, feed)
+				}
+			}
+		})
+	}
+
+	if _, _, err := Apply(entries, feeds, "newest"); err == nil {
+		t.Error("Apply() with an unknown rule succeeded")
+	}
+}
+
+func TestApply_LocalIDs(t *testing.T) {
+	blog := "https://blog.example.com/feed"
+	news := "https://news.example.org/feed"
+	entries := []cache.Entry{
+		// Numeric GUIDs are only unique within a feed
+		{Title: "Hello", Link: "https://blog.example.com/hello", ID: "42", ChannelURL: blog},
+		{Title: "Release notes", Link: "https://news.example.org/release", ID: "42", ChannelURL: news},
+		// With a matching title they are the same article
+		{Title: "Shared", Link: "https://blog.example.com/shared", ID: "7", ChannelURL: blog},
+		{Title: "shared ", Link: "https://mirror.example.org/shared", ID: "7", ChannelURL: news},
+	}
+
+	kept, dropped, err := Apply(entries, nil, KeepEarliest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dropped != 1 || len(kept) != 3 {
+		t.Fatalf("Apply() dropped %d, kept %d, want 1 and 3", dropped, len(kept))
+	}
+	if kept[0].Title != "Hello" || kept[1].Title != "Release notes" {
+		t.Errorf("Apply() kept %q and %q, want entries sharing only a numeric GUID", kept[0].Title, kept[1].Title)
+	}
+}
+
+func TestApply_SameFeed(t *testing.T) {
+	feedURL := "https://example.com/feed"
+	entries := []cache.Entry{
+		{Title: "Part 1", Link: "https://example.com/series", ID: "1", ChannelURL: feedURL},
+		{Title: "Part 2", Link: "https://example.com/series", ID: "2", ChannelURL: feedURL},
+	}
+
+	kept, dropped, err := Apply(entries, nil, KeepEarliest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dropped != 0 || len(kept) != 2 {
+		t.Errorf("Apply() dropped %d, kept %d, want entries of a single feed left alone", dropped, len(kept))
+	}
+}
@@ -0,0 +1,108 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+// backlogFeed returns an RSS feed with items 1..n, item n being the newest
+func backlogFeed(n int) string {
+	var items strings.Builder
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&items, `<item><title>Item %d</title><link>http://example.com/%d</link><pubDate>%s</pubDate></item>`,
+			i, i, start.AddDate(0, 0, i).Format(time.RFC1123Z))
+	}
+	return `<?xml version="1.0"?><rss version="2.0"><channel><title>Test Feed</title>` + items.String() + `</channel></rss>`
+}
+
+func TestSequentialFetcher_NewFeedItems(t *testing.T) {
+	var items atomic.Int32
+	items.Store(5)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(backlogFeed(int(items.Load()))))
+	}))
+	defer server.Close()
+
+	titles := func(c *cache.Cache) string {
+		t.Helper()
+		entries, err := c.LoadAll(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var titles []string
+		for _, entry := range entries {
+			titles = append(titles, entry.Title)
+		}
+		return strings.Join(titles, ",")
+	}
+
+	c := cache.New(t.TempDir())
+	fetcher := NewSequential(20, c, false)
+	fetcher.SetNewFeedItems(2)
+	feeds := []config.FeedConfig{{URL: server.URL}}
+
+	fetcher.FetchFeeds(context.Background(), feeds)
+	if got := titles(c); got != "Item 5,Item 4" {
+		t.Errorf("after first fetch: entries = %q, want %q", got, "Item 5,Item 4")
+	}
+
+	// Backlog entries must not come back as new on later fetches
+	items.Store(6)
+	fetcher.FetchFeeds(context.Background(), feeds)
+	if got := titles(c); got != "Item 6,Item 5,Item 4" {
+		t.Errorf("after second fetch: entries = %q, want %q", got, "Item 6,Item 5,Item 4")
+	}
+
+	// The full backlog stays in the cache
+	cached, _ := c.LoadEntries(server.URL)
+	if len(cached) != 6 {
+		t.Errorf("len(cached) = %d, want 6", len(cached))
+	}
+}
+
+func TestParallelFetcher_NewFeedItemsOverride(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(backlogFeed(5)))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		override string
+		want     int
+	}{
+		{"feed limit", "3", 3},
+		{"no limit", "0", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cache.New(t.TempDir())
+			fetcher := NewParallel(20, c, false, 2)
+			fetcher.SetNewFeedItems(1)
+
+			feed := config.FeedConfig{URL: server.URL, Extra: map[string]string{"new_feed_items": tt.override}}
+			fetcher.FetchFeeds(context.Background(), []config.FeedConfig{feed})
+
+			entries, err := c.LoadAll(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != tt.want {
+				t.Errorf("len(entries) = %d, want %d", len(entries), tt.want)
+			}
+		})
+	}
+}
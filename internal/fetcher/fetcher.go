@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"sort"
 	"sync"
 	"time"
 
@@ -23,10 +24,15 @@ type Fetcher interface {
 
 // FetchResult contains the result of fetching a single feed
 type FetchResult struct {
-	URL     string
-	Entries []cache.Entry
-	Cached  bool
-	Error   error
+	URL      string
+	Entries  []cache.Entry
+	Cached   bool
+	Error    error
+	Delayed  time.Duration // Time spent waiting for the per-host limiter
+	Attempts int           // Number of HTTP requests made, including retries
+	MovedTo  string        // New feed URL if the feed was permanently redirected
+	Gone     bool          // Feed returned 410 Gone
+	Skipped  bool          // Feed was not fetched because it keeps failing
 }
 
 // SequentialFetcher fetches feeds one at a time
@@ -36,6 +42,10 @@ type SequentialFetcher struct {
 	cache   *cache.Cache
 	parser  *gofeed.Parser
 	debug   bool
+	retry   RetryPolicy
+	health  HealthPolicy
+
+	newFeedItems int
 }
 
 // NewSequential creates a new sequential fetcher
@@ -80,12 +90,30 @@ func NewSequential(timeoutSeconds int, cache *cache.Cache, debug bool) *Sequenti
 	}
 }
 
+// SetRetryPolicy enables retries of transient fetch failures
+func (f *SequentialFetcher) SetRetryPolicy(policy RetryPolicy) {
+	f.retry = policy
+}
+
+// SetHealthPolicy enables skipping of feeds that keep failing
+func (f *SequentialFetcher) SetHealthPolicy(policy HealthPolicy) {
+	f.health = policy
+}
+
+// SetNewFeedItems limits how many entries of a newly added feed are shown
+// (new_feed_items); a feed-level new_feed_items overrides it
+func (f *SequentialFetcher) SetNewFeedItems(n int) {
+	f.newFeedItems = n
+}
+
 // FetchFeeds fetches all feeds sequentially
 func (f *SequentialFetcher) FetchFeeds(ctx context.Context, feeds []config.FeedConfig) []FetchResult {
 	results := make([]FetchResult, 0, len(feeds))
 
 	for _, feed := range feeds {
-		result := f.fetchOne(ctx, feed)
+		result := fetchWithHealth(f.cache, f.health, feed, func() FetchResult {
+			return f.fetchOne(ctx, feed)
+		})
 		results = append(results, result)
 	}
 
@@ -139,17 +167,19 @@ func (f *SequentialFetcher) fetchOne(ctx context.Context, feed config.FeedConfig
 		}
 	}
 
-	// Fetch feed
+	// Fetch feed (retrying transient failures)
 	slog.Debug("sending HTTP request", "url", feed.URL, "timeout", f.timeout)
 	fetchStart := time.Now()
-	resp, err := f.client.Do(req)
+	resp, attempts, _, err := doWithRetry(ctx, f.client, req, f.retry, nil)
 	fetchDuration := time.Since(fetchStart)
+	result.Attempts = attempts
 
 	if err != nil {
 		result.Error = fmt.Errorf("fetch feed: %w", err)
 		slog.Error("HTTP request failed",
 			"url", feed.URL,
 			"error", err,
+			"attempts", attempts,
 			"duration", fetchDuration)
 
 		// Try to load from cache
@@ -171,6 +201,11 @@ func (f *SequentialFetcher) fetchOne(ctx context.Context, feed config.FeedConfig
 		"content_type", resp.Header.Get("Content-Type"),
 		"duration", fetchDuration)
 
+	// Record permanent redirects and gone feeds for "planet feeds fix"
+	result.MovedTo = permanentRedirect(feed.URL, resp)
+	result.Gone = resp.StatusCode == http.StatusGone
+	recordSubscriptionStatus(f.cache, feed.URL, meta, result.MovedTo, result.Gone)
+
 	// Handle 304 Not Modified
 	if resp.StatusCode == http.StatusNotModified {
 		slog.Debug("feed not modified, using cache", "url", feed.URL)
@@ -195,7 +230,8 @@ func (f *SequentialFetcher) fetchOne(ctx context.Context, feed config.FeedConfig
 		slog.Error("unexpected HTTP status",
 			"url", feed.URL,
 			"status", resp.StatusCode,
-			"status_text", resp.Status)
+			"status_text", resp.Status,
+			"attempts", attempts)
 
 		// Try to load from cache
 		slog.Debug("attempting to load from cache", "url", feed.URL)
@@ -255,9 +291,12 @@ func (f *SequentialFetcher) fetchOne(ctx context.Context, feed config.FeedConfig
 	entries := f.convertEntries(parsedFeed, feed)
 	result.Entries = entries
 
+	// A new subscription only shows its newest entries
+	markBacklog(f.cache, feed, entries, f.newFeedItems)
+
 	// Save to cache
 	slog.Debug("saving to cache", "url", feed.URL, "entries", len(entries))
-	if err := f.cache.SaveEntries(feed.URL, entries); err != nil {
+	if err := f.cache.MergeEntries(feed.URL, entries, retentionFor(feed, f.cache.Retention())); err != nil {
 		slog.Warn("failed to save cache", "url", feed.URL, "error", err)
 	} else {
 		slog.Debug("cache saved", "url", feed.URL)
@@ -268,6 +307,7 @@ func (f *SequentialFetcher) fetchOne(ctx context.Context, feed config.FeedConfig
 		LastFetched:  time.Now(),
 		ETag:         resp.Header.Get("ETag"),
 		LastModified: resp.Header.Get("Last-Modified"),
+		MovedTo:      result.MovedTo,
 	}
 	if err := f.cache.SaveMetadata(feed.URL, newMeta); err != nil {
 		slog.Warn("failed to save metadata", "url", feed.URL, "error", err)
@@ -329,7 +369,9 @@ func (f *SequentialFetcher) convertEntries(feed *gofeed.Feed, feedConfig config.
 			date = *item.PublishedParsed
 		} else if item.UpdatedParsed != nil {
 			date = *item.UpdatedParsed
-		} else if feed.UpdatedParsed != nil {
+		}
+		itemDate := date // The item's own date, used for synthetic IDs
+		if date.IsZero() && feed.UpdatedParsed != nil {
 			// If the channel/feed has an updated timestamp, use it as a last-resort
 			date = *feed.UpdatedParsed
 		}
@@ -357,7 +399,8 @@ func (f *SequentialFetcher) convertEntries(feed *gofeed.Feed, feedConfig config.
 			Author:       author,
 			AuthorEmail:  authorEmail,
 			Date:         date,
-			ID:           item.GUID,
+			ID:           cache.StableID(feedConfig.URL, item.GUID, item.Link, item.Title, itemDate),
+			Categories:   item.Categories,
 			ChannelName:  channelName,
 			ChannelLink:  feed.Link,
 			ChannelTitle: feed.Title,
@@ -389,6 +432,11 @@ type ParallelFetcher struct {
 	parser  *gofeed.Parser
 	debug   bool
 	workers int
+	limiter *hostLimiter
+	retry   RetryPolicy
+	health  HealthPolicy
+
+	newFeedItems int
 }
 
 // NewParallel creates a new parallel fetcher with specified number of workers
@@ -435,6 +483,29 @@ func NewParallel(timeoutSeconds int, cache *cache.Cache, debug bool, workers int
 	}
 }
 
+// SetHostLimits enables per-host politeness: at most maxPerHost concurrent
+// requests to the same host, and at least delay between their start times.
+// Zero values disable the respective limit.
+func (f *ParallelFetcher) SetHostLimits(maxPerHost int, delay time.Duration) {
+	f.limiter = newHostLimiter(maxPerHost, delay)
+}
+
+// SetRetryPolicy enables retries of transient fetch failures
+func (f *ParallelFetcher) SetRetryPolicy(policy RetryPolicy) {
+	f.retry = policy
+}
+
+// SetHealthPolicy enables skipping of feeds that keep failing
+func (f *ParallelFetcher) SetHealthPolicy(policy HealthPolicy) {
+	f.health = policy
+}
+
+// SetNewFeedItems limits how many entries of a newly added feed are shown
+// (new_feed_items); a feed-level new_feed_items overrides it
+func (f *ParallelFetcher) SetNewFeedItems(n int) {
+	f.newFeedItems = n
+}
+
 // FetchFeeds fetches all feeds in parallel using a worker pool
 func (f *ParallelFetcher) FetchFeeds(ctx context.Context, feeds []config.FeedConfig) []FetchResult {
 	numFeeds := len(feeds)
@@ -471,7 +542,9 @@ func (f *ParallelFetcher) FetchFeeds(ctx context.Context, feeds []config.FeedCon
 					"worker_id", workerID,
 					"url", feed.URL)
 
-				result := f.fetchOne(ctx, feed)
+				result := fetchWithHealth(f.cache, f.health, feed, func() FetchResult {
+					return f.fetchOne(ctx, feed)
+				})
 				resultsChan <- result
 			}
 
@@ -479,9 +552,10 @@ func (f *ParallelFetcher) FetchFeeds(ctx context.Context, feeds []config.FeedCon
 		}(i)
 	}
 
-	// Send all feeds to the work channel
+	// Send all feeds to the work channel, spread over hosts so that
+	// workers don't queue up behind the per-host limiter
 	go func() {
-		for _, feed := range feeds {
+		for _, feed := range interleaveByHost(feeds) {
 			feedsChan <- feed
 		}
 		close(feedsChan)
@@ -556,17 +630,30 @@ func (f *ParallelFetcher) fetchOne(ctx context.Context, feed config.FeedConfig)
 		}
 	}
 
-	// Fetch feed
+	// Fetch feed (retrying transient failures), waiting for the per-host
+	// limiter before every attempt
 	slog.Debug("sending HTTP request", "url", feed.URL, "timeout", f.timeout)
+	host := hostKey(feed.URL)
 	fetchStart := time.Now()
-	resp, err := f.client.Do(req)
+	resp, attempts, delayed, err := doWithRetry(ctx, f.client, req, f.retry, func() (time.Duration, func(), error) {
+		return f.limiter.acquire(ctx, host)
+	})
 	fetchDuration := time.Since(fetchStart)
+	result.Attempts = attempts
+	result.Delayed = delayed
+	if delayed > 0 {
+		slog.Debug("request delayed by host limiter",
+			"url", feed.URL,
+			"host", host,
+			"delay", delayed)
+	}
 
 	if err != nil {
 		result.Error = fmt.Errorf("fetch feed: %w", err)
 		slog.Error("HTTP request failed",
 			"url", feed.URL,
 			"error", err,
+			"attempts", attempts,
 			"duration", fetchDuration)
 
 		// Try to load from cache
@@ -588,6 +675,11 @@ func (f *ParallelFetcher) fetchOne(ctx context.Context, feed config.FeedConfig)
 		"content_type", resp.Header.Get("Content-Type"),
 		"duration", fetchDuration)
 
+	// Record permanent redirects and gone feeds for "planet feeds fix"
+	result.MovedTo = permanentRedirect(feed.URL, resp)
+	result.Gone = resp.StatusCode == http.StatusGone
+	recordSubscriptionStatus(f.cache, feed.URL, meta, result.MovedTo, result.Gone)
+
 	// Handle 304 Not Modified
 	if resp.StatusCode == http.StatusNotModified {
 		slog.Debug("feed not modified, using cache", "url", feed.URL)
@@ -612,7 +704,8 @@ func (f *ParallelFetcher) fetchOne(ctx context.Context, feed config.FeedConfig)
 		slog.Error("unexpected HTTP status",
 			"url", feed.URL,
 			"status", resp.StatusCode,
-			"status_text", resp.Status)
+			"status_text", resp.Status,
+			"attempts", attempts)
 
 		// Try to load from cache
 		slog.Debug("attempting to load from cache", "url", feed.URL)
@@ -672,9 +765,12 @@ func (f *ParallelFetcher) fetchOne(ctx context.Context, feed config.FeedConfig)
 	entries := f.convertEntries(parsedFeed, feed)
 	result.Entries = entries
 
+	// A new subscription only shows its newest entries
+	markBacklog(f.cache, feed, entries, f.newFeedItems)
+
 	// Save to cache
 	slog.Debug("saving to cache", "url", feed.URL, "entries", len(entries))
-	if err := f.cache.SaveEntries(feed.URL, entries); err != nil {
+	if err := f.cache.MergeEntries(feed.URL, entries, retentionFor(feed, f.cache.Retention())); err != nil {
 		slog.Warn("failed to save cache", "url", feed.URL, "error", err)
 	} else {
 		slog.Debug("cache saved", "url", feed.URL)
@@ -685,6 +781,7 @@ func (f *ParallelFetcher) fetchOne(ctx context.Context, feed config.FeedConfig)
 		LastFetched:  time.Now(),
 		ETag:         resp.Header.Get("ETag"),
 		LastModified: resp.Header.Get("Last-Modified"),
+		MovedTo:      result.MovedTo,
 	}
 	if err := f.cache.SaveMetadata(feed.URL, newMeta); err != nil {
 		slog.Warn("failed to save metadata", "url", feed.URL, "error", err)
@@ -746,7 +843,9 @@ func (f *ParallelFetcher) convertEntries(feed *gofeed.Feed, feedConfig config.Fe
 			date = *item.PublishedParsed
 		} else if item.UpdatedParsed != nil {
 			date = *item.UpdatedParsed
-		} else if feed.UpdatedParsed != nil {
+		}
+		itemDate := date // The item's own date, used for synthetic IDs
+		if date.IsZero() && feed.UpdatedParsed != nil {
 			// If the channel/feed has an updated timestamp, use it as a last-resort
 			date = *feed.UpdatedParsed
 		}
@@ -774,7 +873,8 @@ func (f *ParallelFetcher) convertEntries(feed *gofeed.Feed, feedConfig config.Fe
 			Author:       author,
 			AuthorEmail:  authorEmail,
 			Date:         date,
-			ID:           item.GUID,
+			ID:           cache.StableID(feedConfig.URL, item.GUID, item.Link, item.Title, itemDate),
+			Categories:   item.Categories,
 			ChannelName:  channelName,
 			ChannelLink:  feed.Link,
 			ChannelTitle: feed.Title,
@@ -797,3 +897,95 @@ func (f *ParallelFetcher) convertEntries(feed *gofeed.Feed, feedConfig config.Fe
 
 	return entries
 }
+
+// retentionFor returns the cache retention for a feed, applying any
+// feed-level cache_keep_entries / cache_keep_days overrides to the defaults
+func retentionFor(feed config.FeedConfig, defaults cache.Retention) cache.Retention {
+	keepDays := int(defaults.MaxAge / (24 * time.Hour))
+	return cache.Retention{
+		MaxEntries: feed.CacheKeepEntries(defaults.MaxEntries),
+		MaxAge:     time.Duration(feed.CacheKeepDays(keepDays)) * 24 * time.Hour,
+	}
+}
+
+// permanentRedirect returns the URL a feed was permanently moved to, following
+// the leading 301/308 redirects of the response's redirect chain. Temporary
+// redirects end the chain. It returns "" if the feed was not moved.
+func permanentRedirect(feedURL string, resp *http.Response) string {
+	// Each followed redirect's request links to the response that caused it
+	var hops []*http.Request
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		hops = append(hops, req)
+	}
+
+	movedTo := ""
+	for i := len(hops) - 1; i >= 0; i-- {
+		status := hops[i].Response.StatusCode
+		if status != http.StatusMovedPermanently && status != http.StatusPermanentRedirect {
+			break
+		}
+		movedTo = hops[i].URL.String()
+	}
+
+	if movedTo == feedURL {
+		return ""
+	}
+	return movedTo
+}
+
+// recordSubscriptionStatus saves a permanent move or 410 Gone in the feed's
+// cache metadata, so that "planet feeds fix" can update the config later
+func recordSubscriptionStatus(c *cache.Cache, feedURL string, meta *cache.Metadata, movedTo string, gone bool) {
+	if movedTo != "" {
+		slog.Warn("feed permanently moved, run \"planet feeds fix\" to update the config",
+			"url", feedURL,
+			"moved_to", movedTo)
+	}
+	if gone {
+		slog.Warn("feed is gone (410), run \"planet feeds fix\" to disable it",
+			"url", feedURL)
+	}
+
+	var updated cache.Metadata
+	if meta != nil {
+		updated = *meta
+	}
+	if updated.MovedTo == movedTo && updated.Gone == gone {
+		return
+	}
+
+	updated.MovedTo = movedTo
+	updated.Gone = gone
+	if err := c.SaveMetadata(feedURL, updated); err != nil {
+		slog.Warn("failed to save subscription status", "url", feedURL, "error", err)
+	}
+}
+
+// markBacklog marks all but the newest n entries as backlog if the feed has
+// no cached entries yet, so that adding a subscription doesn't flood the
+// front page and the Twitter queue with its old posts. n <= 0 keeps all.
+func markBacklog(c *cache.Cache, feed config.FeedConfig, entries []cache.Entry, def int) {
+	n := feed.NewFeedItems(def)
+	if n <= 0 || len(entries) <= n {
+		return
+	}
+	if cached, err := c.LoadEntries(feed.URL); err != nil || len(cached) > 0 {
+		return
+	}
+
+	newest := make([]int, len(entries))
+	for i := range newest {
+		newest[i] = i
+	}
+	sort.SliceStable(newest, func(i, j int) bool {
+		return entries[newest[i]].Date.After(entries[newest[j]].Date)
+	})
+	for _, i := range newest[n:] {
+		entries[i].Backlog = true
+	}
+
+	slog.Info("new feed, keeping only the newest entries",
+		"url", feed.URL,
+		"kept", n,
+		"backlog", len(entries)-n)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/alexey-ott/planet-go/internal/cache"
 	"github.com/alexey-ott/planet-go/internal/config"
@@ -280,3 +281,63 @@ func TestParallelFetcher_ConditionalGET(t *testing.T) {
 		t.Errorf("requestCount = %d, want 2", requestCount)
 	}
 }
+
+func TestSequentialFetcher_StableIDs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<?xml version="1.0"?>
+<rss version="2.0">
+  <channel>
+    <title>Test Feed</title>
+    <lastBuildDate>Tue, 02 Jan 2024 00:00:00 GMT</lastBuildDate>
+    <item><guid>guid-1</guid><title>With GUID</title><link>http://example.com/1</link></item>
+    <item><title>With link</title><link>http://example.com/2</link></item>
+    <item><title>Title only</title></item>
+    <item><title>Another title</title></item>
+  </channel>
+</rss>`))
+	}))
+	defer server.Close()
+
+	fetcher := NewSequential(20, cache.New(t.TempDir()), false)
+	results := fetcher.FetchFeeds(context.Background(), []config.FeedConfig{{URL: server.URL}})
+
+	entries := results[0].Entries
+	if len(entries) != 4 {
+		t.Fatalf("len(entries) = %d, want 4", len(entries))
+	}
+	if entries[0].ID != "guid-1" {
+		t.Errorf("entries[0].ID = %q, want GUID", entries[0].ID)
+	}
+	if entries[1].ID != "http://example.com/2" {
+		t.Errorf("entries[1].ID = %q, want link", entries[1].ID)
+	}
+	// Items without GUID and link get distinct hashed IDs that don't depend
+	// on the channel's lastBuildDate
+	want := cache.StableID(server.URL, "", "", "Title only", time.Time{})
+	if entries[2].ID != want {
+		t.Errorf("entries[2].ID = %q, want %q", entries[2].ID, want)
+	}
+	if entries[2].ID == entries[3].ID {
+		t.Errorf("entries without GUID and link share the ID %q", entries[2].ID)
+	}
+
+	// A legacy cached copy of the item, which has the channel's date and no
+	// ID, gets the same ID from the migration
+	legacy := entries[2]
+	legacy.ID = ""
+	legacyCache := cache.New(t.TempDir())
+	if err := legacyCache.SaveEntries(server.URL, []cache.Entry{legacy}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := legacyCache.MigrateEntryIDs(); err != nil {
+		t.Fatalf("MigrateEntryIDs() error = %v", err)
+	}
+	migrated, err := legacyCache.LoadEntries(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrated) != 1 || migrated[0].ID != entries[2].ID {
+		t.Errorf("migrated entries = %+v, want ID %q", migrated, entries[2].ID)
+	}
+}
@@ -0,0 +1,112 @@
+package fetcher
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+// latencySamples is the window of the moving average fetch latency
+const latencySamples = 20
+
+// HealthPolicy configures skipping of feeds that keep failing. After
+// MaxFailures consecutive failures a feed is skipped for BaseBackoff, doubled
+// on every further failure up to MaxBackoff. A success resets the feed.
+// The zero value never skips feeds.
+type HealthPolicy struct {
+	MaxFailures int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// backoff returns how long a feed with the given number of consecutive
+// failures is skipped (0 if it is not skipped)
+func (p HealthPolicy) backoff(failures int) time.Duration {
+	if p.MaxFailures <= 0 || failures < p.MaxFailures || p.BaseBackoff <= 0 {
+		return 0
+	}
+
+	delay := p.BaseBackoff
+	for i := p.MaxFailures; i < failures && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+// fetchWithHealth runs fetch unless the feed is being skipped because of
+// earlier failures, and records the outcome in the feed's health record
+func fetchWithHealth(c *cache.Cache, policy HealthPolicy, feed config.FeedConfig, fetch func() FetchResult) FetchResult {
+	health, err := c.LoadHealth(feed.URL)
+	if err != nil {
+		slog.Warn("failed to load feed health", "url", feed.URL, "error", err)
+	}
+
+	now := time.Now()
+	if now.Before(health.SkipUntil) {
+		slog.Info("skipping failing feed",
+			"url", feed.URL,
+			"failures", health.ConsecutiveFailures,
+			"last_error", health.LastError,
+			"skip_until", health.SkipUntil)
+
+		result := FetchResult{URL: feed.URL, Skipped: true}
+		if entries, _ := c.LoadEntries(feed.URL); entries != nil {
+			result.Entries = entries
+			result.Cached = true
+		}
+		return result
+	}
+
+	start := time.Now()
+	result := fetch()
+	latency := time.Since(start) - result.Delayed
+
+	// Reload: the fetch may have updated LastNewEntry
+	if updated, err := c.LoadHealth(feed.URL); err == nil {
+		health = updated
+	}
+	health = updateHealth(health, policy, result, latency, time.Now())
+
+	if health.SkipUntil.After(now) && result.Error != nil {
+		slog.Warn("feed keeps failing, skipping it for a while",
+			"url", feed.URL,
+			"failures", health.ConsecutiveFailures,
+			"skip_until", health.SkipUntil)
+	}
+
+	if err := c.SaveHealth(feed.URL, health); err != nil {
+		slog.Warn("failed to save feed health", "url", feed.URL, "error", err)
+	}
+
+	return result
+}
+
+// updateHealth applies the outcome of a fetch to a health record
+func updateHealth(health cache.Health, policy HealthPolicy, result FetchResult, latency time.Duration, now time.Time) cache.Health {
+	if result.Error != nil {
+		health.ConsecutiveFailures++
+		health.LastError = result.Error.Error()
+		health.LastErrorAt = now
+		if backoff := policy.backoff(health.ConsecutiveFailures); backoff > 0 {
+			health.SkipUntil = now.Add(backoff)
+		}
+		return health
+	}
+
+	health.ConsecutiveFailures = 0
+	health.LastSuccess = now
+	health.SkipUntil = time.Time{}
+
+	// Moving average over the last latencySamples fetches
+	samples := min(health.Fetches, latencySamples-1)
+	ms := float64(latency) / float64(time.Millisecond)
+	health.AvgLatencyMS = (health.AvgLatencyMS*float64(samples) + ms) / float64(samples+1)
+	health.Fetches++
+
+	return health
+}
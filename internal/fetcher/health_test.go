@@ -0,0 +1,135 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+func TestHealthPolicy_Backoff(t *testing.T) {
+	policy := HealthPolicy{MaxFailures: 3, BaseBackoff: time.Hour, MaxBackoff: 5 * time.Hour}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{2, 0},
+		{3, time.Hour},
+		{4, 2 * time.Hour},
+		{5, 4 * time.Hour},
+		{6, 5 * time.Hour}, // Capped at MaxBackoff
+		{50, 5 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := policy.backoff(tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+
+	if got := (HealthPolicy{}).backoff(100); got != 0 {
+		t.Errorf("zero policy backoff(100) = %v, want 0", got)
+	}
+}
+
+func TestUpdateHealth(t *testing.T) {
+	policy := HealthPolicy{MaxFailures: 2, BaseBackoff: time.Hour}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	failure := FetchResult{Error: errors.New("unexpected status: 500")}
+
+	health := updateHealth(cache.Health{}, policy, failure, 0, now)
+	if health.ConsecutiveFailures != 1 || health.LastError != "unexpected status: 500" || !health.LastErrorAt.Equal(now) {
+		t.Errorf("after one failure: %+v", health)
+	}
+	if !health.SkipUntil.IsZero() {
+		t.Errorf("SkipUntil = %v, want zero below the threshold", health.SkipUntil)
+	}
+
+	health = updateHealth(health, policy, failure, 0, now)
+	if want := now.Add(time.Hour); !health.SkipUntil.Equal(want) {
+		t.Errorf("SkipUntil = %v, want %v", health.SkipUntil, want)
+	}
+
+	health = updateHealth(health, policy, FetchResult{}, 100*time.Millisecond, now)
+	if health.ConsecutiveFailures != 0 || !health.SkipUntil.IsZero() || !health.LastSuccess.Equal(now) {
+		t.Errorf("after success: %+v", health)
+	}
+	if health.AvgLatencyMS != 100 {
+		t.Errorf("AvgLatencyMS = %v, want 100", health.AvgLatencyMS)
+	}
+
+	health = updateHealth(health, policy, FetchResult{}, 300*time.Millisecond, now)
+	if health.AvgLatencyMS != 200 {
+		t.Errorf("AvgLatencyMS = %v, want 200", health.AvgLatencyMS)
+	}
+}
+
+func TestSequentialFetcher_SkipsFailingFeed(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	c := cache.New(t.TempDir())
+	fetcher := NewSequential(20, c, false)
+	fetcher.SetHealthPolicy(HealthPolicy{MaxFailures: 2, BaseBackoff: time.Hour})
+	feeds := []config.FeedConfig{{URL: server.URL}}
+
+	for i := 0; i < 3; i++ {
+		results := fetcher.FetchFeeds(context.Background(), feeds)
+		if skipped := results[0].Skipped; skipped != (i == 2) {
+			t.Errorf("fetch %d: Skipped = %v", i+1, skipped)
+		}
+	}
+
+	if got := requests.Load(); got != 2 {
+		t.Errorf("server saw %d requests, want 2", got)
+	}
+
+	health, err := c.LoadHealth(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.ConsecutiveFailures != 2 {
+		t.Errorf("ConsecutiveFailures = %d, want 2", health.ConsecutiveFailures)
+	}
+	if health.LastError != "unexpected status: 404" {
+		t.Errorf("LastError = %q", health.LastError)
+	}
+}
+
+func TestParallelFetcher_RecordsHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(retryTestFeed))
+	}))
+	defer server.Close()
+
+	c := cache.New(t.TempDir())
+	fetcher := NewParallel(20, c, false, 2)
+	fetcher.FetchFeeds(context.Background(), []config.FeedConfig{{URL: server.URL}})
+
+	health, err := c.LoadHealth(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.LastSuccess.IsZero() {
+		t.Error("LastSuccess is zero")
+	}
+	if health.LastNewEntry.IsZero() {
+		t.Error("LastNewEntry is zero")
+	}
+	if health.Fetches != 1 {
+		t.Errorf("Fetches = %d, want 1", health.Fetches)
+	}
+}
@@ -0,0 +1,163 @@
+package fetcher
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexey-ott/planet-go/internal/config"
+	"golang.org/x/net/publicsuffix"
+)
+
+// hostLimiter enforces per-host politeness: at most maxPerHost concurrent
+// requests per host and a minimum delay between request starts to the same host.
+// Zero values disable the respective limit.
+type hostLimiter struct {
+	maxPerHost int
+	delay      time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+// hostState tracks the limiter state of one host
+type hostState struct {
+	slots chan struct{} // Semaphore with maxPerHost capacity (nil if unlimited)
+	next  time.Time     // Earliest time the next request may start
+}
+
+func newHostLimiter(maxPerHost int, delay time.Duration) *hostLimiter {
+	return &hostLimiter{
+		maxPerHost: maxPerHost,
+		delay:      delay,
+		hosts:      make(map[string]*hostState),
+	}
+}
+
+// state returns the state for a host, creating it on first use
+func (l *hostLimiter) state(host string) *hostState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state, ok := l.hosts[host]
+	if !ok {
+		state = &hostState{}
+		if l.maxPerHost > 0 {
+			state.slots = make(chan struct{}, l.maxPerHost)
+		}
+		l.hosts[host] = state
+	}
+	return state
+}
+
+// acquire blocks until a request to host may start. It returns how long the
+// caller was delayed and a release function that must be called once the
+// request is done.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (time.Duration, func(), error) {
+	if l == nil || (l.maxPerHost <= 0 && l.delay <= 0) {
+		return 0, func() {}, nil
+	}
+
+	start := time.Now()
+	state := l.state(host)
+	waited := false
+
+	// Wait for a free slot
+	release := func() {}
+	if state.slots != nil {
+		select {
+		case state.slots <- struct{}{}:
+		default:
+			waited = true
+			select {
+			case state.slots <- struct{}{}:
+			case <-ctx.Done():
+				return time.Since(start), func() {}, ctx.Err()
+			}
+		}
+		release = func() { <-state.slots }
+	}
+
+	// Reserve the next start time for this host
+	if l.delay > 0 {
+		l.mu.Lock()
+		now := time.Now()
+		startAt := state.next
+		if startAt.Before(now) {
+			startAt = now
+		}
+		state.next = startAt.Add(l.delay)
+		l.mu.Unlock()
+
+		if wait := startAt.Sub(now); wait > 0 {
+			waited = true
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				release()
+				return time.Since(start), func() {}, ctx.Err()
+			}
+		}
+	}
+
+	if !waited {
+		return 0, release, nil
+	}
+	return time.Since(start), release, nil
+}
+
+// hostKey returns the limiter key for a feed URL. Subdomains of the same site
+// share a key (feeds.feedburner.com -> feedburner.com), and so do blogs on
+// shared hosting such as *.blogspot.com or *.github.io.
+func hostKey(feedURL string) string {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return feedURL
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if net.ParseIP(host) != nil {
+		return host
+	}
+
+	// Private suffixes (blogspot.com, github.io, ...) are run by one operator
+	suffix, icann := publicsuffix.PublicSuffix(host)
+	if !icann && suffix != host && strings.Contains(suffix, ".") {
+		return suffix
+	}
+
+	if key, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return key
+	}
+	return host
+}
+
+// interleaveByHost reorders feeds round-robin by host key, so that workers
+// are spread over many hosts instead of queueing on the same one
+func interleaveByHost(feeds []config.FeedConfig) []config.FeedConfig {
+	var order []string
+	byHost := make(map[string][]config.FeedConfig)
+	for _, feed := range feeds {
+		key := hostKey(feed.URL)
+		if _, ok := byHost[key]; !ok {
+			order = append(order, key)
+		}
+		byHost[key] = append(byHost[key], feed)
+	}
+
+	interleaved := make([]config.FeedConfig, 0, len(feeds))
+	for len(interleaved) < len(feeds) {
+		for _, key := range order {
+			if queue := byHost[key]; len(queue) > 0 {
+				interleaved = append(interleaved, queue[0])
+				byHost[key] = queue[1:]
+			}
+		}
+	}
+	return interleaved
+}
@@ -0,0 +1,170 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+func TestHostKey(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://feeds.feedburner.com/clojure", "feedburner.com"},
+		{"http://FEEDS.Feedburner.com/other", "feedburner.com"},
+		{"https://someone.blogspot.com/feeds/posts/default", "blogspot.com"},
+		{"https://alexott.github.io/feed.xml", "github.io"},
+		{"https://www.bbc.co.uk/news/rss.xml", "bbc.co.uk"},
+		{"http://127.0.0.1:8080/feed", "127.0.0.1"},
+		{"http://localhost/feed", "localhost"},
+	}
+
+	for _, tt := range tests {
+		if got := hostKey(tt.url); got != tt.want {
+			t.Errorf("hostKey(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestInterleaveByHost(t *testing.T) {
+	feeds := []config.FeedConfig{
+		{URL: "https://a.blogspot.com/feed"},
+		{URL: "https://b.blogspot.com/feed"},
+		{URL: "https://c.blogspot.com/feed"},
+		{URL: "https://example.com/feed"},
+		{URL: "https://example.org/feed"},
+	}
+
+	got := interleaveByHost(feeds)
+	want := []string{
+		"https://a.blogspot.com/feed",
+		"https://example.com/feed",
+		"https://example.org/feed",
+		"https://b.blogspot.com/feed",
+		"https://c.blogspot.com/feed",
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("len(got) = %d, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].URL != want[i] {
+			t.Errorf("got[%d].URL = %q, want %q", i, got[i].URL, want[i])
+		}
+	}
+}
+
+func TestHostLimiter_Delay(t *testing.T) {
+	limiter := newHostLimiter(0, 50*time.Millisecond)
+	ctx := context.Background()
+
+	delayed, release, err := limiter.acquire(ctx, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if delayed > 10*time.Millisecond {
+		t.Errorf("first request delayed by %v, want no delay", delayed)
+	}
+
+	delayed, release, err = limiter.acquire(ctx, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if delayed < 40*time.Millisecond {
+		t.Errorf("second request delayed by %v, want ~50ms", delayed)
+	}
+
+	// Other hosts are not affected
+	delayed, release, err = limiter.acquire(ctx, "example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if delayed > 10*time.Millisecond {
+		t.Errorf("other host delayed by %v, want no delay", delayed)
+	}
+}
+
+func TestHostLimiter_ContextCanceled(t *testing.T) {
+	limiter := newHostLimiter(1, 0)
+
+	_, release, err := limiter.acquire(context.Background(), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, _, err := limiter.acquire(ctx, "example.com"); err == nil {
+		t.Error("acquire() should fail when the context is canceled while waiting")
+	}
+}
+
+func TestParallelFetcher_MaxPerHost(t *testing.T) {
+	var inFlight, maxInFlight int32
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		mu.Lock()
+		if current > maxInFlight {
+			maxInFlight = current
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<?xml version="1.0"?>
+<rss version="2.0">
+  <channel>
+    <title>Test Feed</title>
+    <item><title>Test Item</title><link>http://example.com/1</link></item>
+  </channel>
+</rss>`))
+	}))
+	defer server.Close()
+
+	fetcher := NewParallel(20, cache.New(t.TempDir()), false, 5)
+	fetcher.SetHostLimits(2, 0)
+
+	feeds := make([]config.FeedConfig, 8)
+	for i := range feeds {
+		feeds[i] = config.FeedConfig{URL: server.URL + "/" + string(rune('a'+i)), Name: "Test Feed"}
+	}
+
+	results := fetcher.FetchFeeds(context.Background(), feeds)
+	if len(results) != len(feeds) {
+		t.Fatalf("len(results) = %d, want %d", len(results), len(feeds))
+	}
+
+	delayed := 0
+	for _, result := range results {
+		if result.Error != nil {
+			t.Errorf("result.Error = %v, want nil", result.Error)
+		}
+		if result.Delayed > 0 {
+			delayed++
+		}
+	}
+
+	if maxInFlight > 2 {
+		t.Errorf("max concurrent requests = %d, want <= 2", maxInFlight)
+	}
+	if delayed == 0 {
+		t.Error("no result reported a limiter delay")
+	}
+}
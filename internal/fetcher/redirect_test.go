@@ -0,0 +1,97 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+func TestSequentialFetcher_PermanentRedirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/temp", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	})
+	mux.HandleFunc("/chain", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/temp", http.StatusPermanentRedirect)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(retryTestFeed))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		path        string
+		wantMovedTo string
+	}{
+		{"/old", server.URL + "/new"},
+		{"/temp", ""},
+		{"/chain", server.URL + "/temp"}, // Only the permanent part of the chain counts
+		{"/new", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			c := cache.New(t.TempDir())
+			fetcher := NewSequential(20, c, false)
+			feedURL := server.URL + tt.path
+
+			results := fetcher.FetchFeeds(context.Background(), []config.FeedConfig{{URL: feedURL}})
+
+			result := results[0]
+			if result.Error != nil {
+				t.Fatalf("result.Error = %v, want nil", result.Error)
+			}
+			if result.MovedTo != tt.wantMovedTo {
+				t.Errorf("result.MovedTo = %q, want %q", result.MovedTo, tt.wantMovedTo)
+			}
+
+			meta, err := c.LoadMetadata(feedURL)
+			if err != nil || meta == nil {
+				t.Fatalf("LoadMetadata() = %v, %v", meta, err)
+			}
+			if meta.MovedTo != tt.wantMovedTo {
+				t.Errorf("meta.MovedTo = %q, want %q", meta.MovedTo, tt.wantMovedTo)
+			}
+		})
+	}
+}
+
+func TestParallelFetcher_Gone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	c := cache.New(t.TempDir())
+	fetcher := NewParallel(20, c, false, 2)
+
+	results := fetcher.FetchFeeds(context.Background(), []config.FeedConfig{{URL: server.URL}})
+
+	result := results[0]
+	if result.Error == nil {
+		t.Error("result.Error = nil, want error")
+	}
+	if !result.Gone {
+		t.Error("result.Gone = false, want true")
+	}
+	if result.Attempts != 1 {
+		t.Errorf("result.Attempts = %d, want 1 (410 is not retried)", result.Attempts)
+	}
+
+	meta, err := c.LoadMetadata(server.URL)
+	if err != nil || meta == nil {
+		t.Fatalf("LoadMetadata() = %v, %v", meta, err)
+	}
+	if !meta.Gone {
+		t.Error("meta.Gone = false, want true")
+	}
+}
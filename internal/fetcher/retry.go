@@ -0,0 +1,189 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy configures retries of transient fetch failures: timeouts,
+// connection resets, 429 Too Many Requests and 5xx responses.
+// The zero value disables retries.
+type RetryPolicy struct {
+	MaxRetries int           // Retries after the first attempt
+	BaseDelay  time.Duration // Backoff before the first retry, doubled on every retry
+	MaxDelay   time.Duration // Upper bound for backoff and Retry-After waits
+}
+
+// backoff returns the jittered exponential backoff before retry number attempt
+// (1-based): a random duration between half and all of BaseDelay * 2^(attempt-1)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// nextDelay decides whether a failed attempt should be retried and how long to
+// wait first. A Retry-After header takes precedence over the backoff; if it
+// asks for a longer wait than MaxDelay the fetch is not retried.
+func (p RetryPolicy) nextDelay(attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if attempt > p.MaxRetries {
+		return 0, false
+	}
+
+	if err != nil {
+		return p.backoff(attempt), isTransientError(err)
+	}
+
+	if !isTransientStatus(resp.StatusCode) {
+		return 0, false
+	}
+
+	if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		if p.MaxDelay > 0 && wait > p.MaxDelay {
+			return 0, false
+		}
+		return wait, true
+	}
+
+	return p.backoff(attempt), true
+}
+
+// isTransientStatus reports whether an HTTP status is worth retrying
+func isTransientStatus(status int) bool {
+	return status == http.StatusTooManyRequests ||
+		(status >= 500 && status != http.StatusNotImplemented)
+}
+
+// isTransientError reports whether a request error is worth retrying
+func isTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := date.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// acquireFunc waits for permission to send a request; it returns how long the
+// caller was delayed and a function releasing the permission
+type acquireFunc func() (time.Duration, func(), error)
+
+// doWithRetry sends req, retrying transient failures according to policy.
+// If acquire is not nil it is called before every attempt and the permission
+// is held until the returned response body is closed.
+// It returns the response, the number of attempts and the total limiter delay.
+func doWithRetry(ctx context.Context, client *http.Client, req *http.Request, policy RetryPolicy, acquire acquireFunc) (*http.Response, int, time.Duration, error) {
+	var delayed time.Duration
+
+	for attempt := 1; ; attempt++ {
+		release := func() {}
+		if acquire != nil {
+			wait, releaseFunc, err := acquire()
+			delayed += wait
+			if err != nil {
+				return nil, attempt, delayed, fmt.Errorf("wait for host limiter: %w", err)
+			}
+			release = releaseFunc
+		}
+
+		resp, err := client.Do(req)
+		if resp != nil {
+			resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+		} else {
+			release()
+		}
+
+		wait, retry := policy.nextDelay(attempt, resp, err)
+		if !retry {
+			return resp, attempt, delayed, err
+		}
+
+		if resp != nil {
+			slog.Warn("transient HTTP status, retrying",
+				"url", req.URL.String(),
+				"status", resp.StatusCode,
+				"attempt", attempt,
+				"retry_after", resp.Header.Get("Retry-After"),
+				"wait", wait)
+			// Drain a little of the body so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		} else {
+			slog.Warn("transient fetch error, retrying",
+				"url", req.URL.String(),
+				"error", err,
+				"attempt", attempt,
+				"wait", wait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, attempt, delayed, ctx.Err()
+		}
+	}
+}
+
+// releaseOnClose releases a limiter permission when the body is closed
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+	closed  bool
+}
+
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	if !r.closed {
+		r.closed = true
+		r.release()
+	}
+	return err
+}
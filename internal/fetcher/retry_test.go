@@ -0,0 +1,201 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+const retryTestFeed = `<?xml version="1.0"?>
+<rss version="2.0">
+  <channel>
+    <title>Test Feed</title>
+    <link>http://example.com</link>
+    <item>
+      <title>Test Item</title>
+      <link>http://example.com/1</link>
+    </item>
+  </channel>
+</rss>`
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"5", 5 * time.Second, true},
+		{"0", 0, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{"Mon, 01 Jan 2024 12:00:30 GMT", 30 * time.Second, true},
+		{"Mon, 01 Jan 2024 11:00:00 GMT", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestRetryPolicy_NextDelay(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 2, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	response := func(status int, retryAfter string) *http.Response {
+		resp := &http.Response{StatusCode: status, Header: http.Header{}}
+		if retryAfter != "" {
+			resp.Header.Set("Retry-After", retryAfter)
+		}
+		return resp
+	}
+
+	tests := []struct {
+		name      string
+		attempt   int
+		resp      *http.Response
+		err       error
+		wantRetry bool
+		wantDelay time.Duration // Exact delay, checked if not zero
+	}{
+		{"503 is retried", 1, response(503, ""), nil, true, 0},
+		{"429 honors Retry-After", 1, response(429, "1"), nil, true, time.Second},
+		{"Retry-After above max is not retried", 1, response(429, "120"), nil, false, 0},
+		{"404 is not retried", 1, response(404, ""), nil, false, 0},
+		{"501 is not retried", 1, response(501, ""), nil, false, 0},
+		{"retries exhausted", 3, response(503, ""), nil, false, 0},
+		{"canceled is not retried", 1, nil, context.Canceled, false, 0},
+		{"deadline exceeded is retried", 1, nil, context.DeadlineExceeded, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, retry := policy.nextDelay(tt.attempt, tt.resp, tt.err)
+			if retry != tt.wantRetry {
+				t.Fatalf("retry = %v, want %v", retry, tt.wantRetry)
+			}
+			if tt.wantDelay != 0 && delay != tt.wantDelay {
+				t.Errorf("delay = %v, want %v", delay, tt.wantDelay)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 150 * time.Millisecond, 300 * time.Millisecond}, // Capped at MaxDelay
+		{10, 150 * time.Millisecond, 300 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		for range 20 {
+			got := policy.backoff(tt.attempt)
+			if got < tt.min || got > tt.max {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", tt.attempt, got, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestSequentialFetcher_RetriesServerError(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(retryTestFeed))
+	}))
+	defer server.Close()
+
+	fetcher := NewSequential(20, cache.New(t.TempDir()), false)
+	fetcher.SetRetryPolicy(RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Second})
+
+	results := fetcher.FetchFeeds(context.Background(), []config.FeedConfig{{URL: server.URL, Name: "Test Feed"}})
+
+	result := results[0]
+	if result.Error != nil {
+		t.Fatalf("result.Error = %v, want nil", result.Error)
+	}
+	if result.Attempts != 2 {
+		t.Errorf("result.Attempts = %d, want 2", result.Attempts)
+	}
+	if len(result.Entries) != 1 {
+		t.Errorf("len(result.Entries) = %d, want 1", len(result.Entries))
+	}
+}
+
+func TestParallelFetcher_RetryAfter(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(retryTestFeed))
+	}))
+	defer server.Close()
+
+	fetcher := NewParallel(20, cache.New(t.TempDir()), false, 2)
+	fetcher.SetHostLimits(1, 0)
+	fetcher.SetRetryPolicy(RetryPolicy{MaxRetries: 1, BaseDelay: time.Hour, MaxDelay: time.Hour})
+
+	start := time.Now()
+	results := fetcher.FetchFeeds(context.Background(), []config.FeedConfig{{URL: server.URL, Name: "Test Feed"}})
+
+	result := results[0]
+	if result.Error != nil {
+		t.Fatalf("result.Error = %v, want nil", result.Error)
+	}
+	if result.Attempts != 2 {
+		t.Errorf("result.Attempts = %d, want 2", result.Attempts)
+	}
+	// Retry-After: 0 must win over the one hour backoff
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("fetch took %v, Retry-After was not honored", elapsed)
+	}
+}
+
+func TestSequentialFetcher_RetriesExhausted(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	fetcher := NewSequential(20, cache.New(t.TempDir()), false)
+	fetcher.SetRetryPolicy(RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Second})
+
+	results := fetcher.FetchFeeds(context.Background(), []config.FeedConfig{{URL: server.URL, Name: "Test Feed"}})
+
+	if results[0].Error == nil {
+		t.Fatal("result.Error = nil, want error")
+	}
+	if results[0].Attempts != 3 {
+		t.Errorf("result.Attempts = %d, want 3", results[0].Attempts)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("server saw %d requests, want 3", got)
+	}
+}
@@ -0,0 +1,73 @@
+package filter
+
+import (
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+// Kinds of patterns that reject entries
+const (
+	KindFilter  = "filter"  // The entry does not match an include pattern
+	KindExclude = "exclude" // The entry matches an exclude pattern
+)
+
+// Rejection explains why ApplyPerFeed drops an entry
+type Rejection struct {
+	Entry   cache.Entry
+	Rule    string   // Effective filter of the entry's feed
+	Reasons []Reason // Patterns that rejected the entry
+}
+
+// Reason is a pattern that rejected an entry
+type Reason struct {
+	Kind    string `json:"kind"`
+	Pattern string `json:"pattern"`
+	Spans   []Span `json:"matches,omitempty"` // Text matched by an exclude pattern
+}
+
+// Explain applies the filters like ApplyPerFeed and returns the entries it
+// would drop, in the order of entries, with the patterns that rejected them
+func Explain(entries []cache.Entry, feedConfigs []config.FeedConfig, globalInclude, globalExclude, globalMode string) ([]Rejection, error) {
+	feedRules, err := newRules(feedConfigs, globalInclude, globalExclude, globalMode)
+	if err != nil {
+		return nil, err
+	}
+
+	var rejections []Rejection
+	for _, entry := range entries {
+		r, hasRule := feedRules[entry.ChannelURL]
+		if !hasRule || r.keep(entry) {
+			continue
+		}
+		rejections = append(rejections, Rejection{
+			Entry:   entry,
+			Rule:    r.String(),
+			Reasons: r.reasons(entry),
+		})
+	}
+	return rejections, nil
+}
+
+// reasons returns the patterns that make the rule reject an entry
+func (r *rule) reasons(entry cache.Entry) []Reason {
+	switch {
+	case r.global == nil:
+		return r.feed.reasons(entry)
+	case r.feed == nil:
+		return r.global.reasons(entry)
+	default:
+		// In and mode either side may reject, in or mode both sides did
+		return append(r.global.reasons(entry), r.feed.reasons(entry)...)
+	}
+}
+
+// reasons returns the pattern that makes the filter reject an entry, if any
+func (f *Filter) reasons(entry cache.Entry) []Reason {
+	if f.include != nil && !f.include.Match(entry) {
+		return []Reason{{Kind: KindFilter, Pattern: f.include.String()}}
+	}
+	if f.exclude != nil && f.exclude.Match(entry) {
+		return []Reason{{Kind: KindExclude, Pattern: f.exclude.String(), Spans: f.exclude.Spans(entry)}}
+	}
+	return nil
+}
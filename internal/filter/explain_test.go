@@ -0,0 +1,86 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+func TestExplain(t *testing.T) {
+	entries := []cache.Entry{
+		{Title: "Clojure Post", Content: "<p>Macros</p>", ChannelURL: "https://blog1.com/feed"},
+		{Title: "Python Post", Content: `<a href="/clojure">link</a>`, ChannelURL: "https://blog1.com/feed"},
+		{Title: "Clojure Jobs", Content: "<p>We are <b>hiring</b> now</p>", ChannelURL: "https://blog1.com/feed"},
+		{Title: "Rust Post", ChannelURL: "https://blog2.com/feed"},
+	}
+	feedConfigs := []config.FeedConfig{
+		{URL: "https://blog1.com/feed", Extra: map[string]string{"exclude": "expr: content:hiring"}},
+		{URL: "https://blog2.com/feed", Extra: map[string]string{}},
+	}
+
+	rejections, err := Explain(entries, feedConfigs, "(?i)clojure", "", ModeAnd)
+	if err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	if len(rejections) != 3 {
+		t.Fatalf("len(Explain()) = %d, want 3", len(rejections))
+	}
+
+	// The link to /clojure is markup, not text
+	python := rejections[0]
+	if python.Entry.Title != "Python Post" {
+		t.Fatalf("rejections[0].Entry.Title = %q, want %q", python.Entry.Title, "Python Post")
+	}
+	if len(python.Reasons) != 1 || python.Reasons[0].Kind != KindFilter || python.Reasons[0].Pattern != "(?i)clojure" {
+		t.Errorf("rejections[0].Reasons = %+v, want the global filter", python.Reasons)
+	}
+	if python.Rule != `(filter "(?i)clojure") AND (exclude "expr: content:hiring")` {
+		t.Errorf("rejections[0].Rule = %q", python.Rule)
+	}
+
+	jobs := rejections[1]
+	if len(jobs.Reasons) != 1 || jobs.Reasons[0].Kind != KindExclude {
+		t.Fatalf("rejections[1].Reasons = %+v, want the feed exclude", jobs.Reasons)
+	}
+	want := Span{Field: FieldContent, Text: "hiring", Start: 7, End: 13}
+	if spans := jobs.Reasons[0].Spans; len(spans) != 1 || spans[0] != want {
+		t.Errorf("rejections[1].Reasons[0].Spans = %+v, want [%+v]", spans, want)
+	}
+
+	if rejections[2].Entry.Title != "Rust Post" {
+		t.Errorf("rejections[2].Entry.Title = %q, want %q", rejections[2].Entry.Title, "Rust Post")
+	}
+}
+
+func TestExpr_Spans(t *testing.T) {
+	entry := cache.Entry{Title: "Go <em>generics</em>", Content: "<p>Type parameters</p>", Categories: []string{"Go"}}
+
+	tests := []struct {
+		pattern string
+		want    []Span
+	}{
+		{"generics", []Span{{Field: FieldTitle, Text: "generics", Start: 3, End: 11}}},
+		{"param", []Span{{Field: FieldContent, Text: "param", Start: 5, End: 10}}},
+		{"expr: title:go AND NOT content:rust", []Span{{Field: FieldTitle, Text: "Go", Start: 0, End: 2}}},
+		{"expr: category:go OR title:/rust/", []Span{{Field: FieldCategory, Text: "Go", Start: 0, End: 2}}},
+		{"expr: title:rust", nil},
+	}
+
+	for _, tt := range tests {
+		expr, err := Parse(tt.pattern)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.pattern, err)
+		}
+		got := expr.Spans(entry)
+		if len(got) != len(tt.want) {
+			t.Errorf("Parse(%q).Spans() = %+v, want %+v", tt.pattern, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Parse(%q).Spans()[%d] = %+v, want %+v", tt.pattern, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
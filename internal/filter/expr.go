@@ -0,0 +1,503 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/sanitizer"
+)
+
+// Fields that a filter expression term can match
+const (
+	FieldTitle    = "title"    // Entry title, without markup
+	FieldContent  = "content"  // Entry content, without markup
+	FieldAuthor   = "author"   // Author name and email
+	FieldCategory = "category" // Entry categories (tags)
+	FieldLink     = "link"     // Entry link
+	FieldFeed     = "feed"     // Feed name and URL
+	FieldAny      = "any"      // Title and content, like a term without a field
+)
+
+var fields = map[string]bool{
+	FieldTitle:    true,
+	FieldContent:  true,
+	FieldAuthor:   true,
+	FieldCategory: true,
+	FieldLink:     true,
+	FieldFeed:     true,
+	FieldAny:      true,
+}
+
+// ExprPrefix marks a pattern written in the expression language. Patterns
+// without it are plain regular expressions, so that regexes written before
+// expressions existed keep their meaning.
+const ExprPrefix = "expr:"
+
+// Expr is a compiled filter or exclude pattern. Patterns are either
+// expressions, marked by ExprPrefix, such as
+//
+//	expr: title:/clojure/i AND NOT author:"bot" OR category:clojure
+//
+// or plain regular expressions, which match the title and content as before.
+// Both match the text without HTML markup.
+type Expr struct {
+	source string
+	root   node
+}
+
+// node is an element of a parsed expression
+type node interface {
+	match(d *document) bool
+
+	// spans returns the text matched by the terms that make a matching
+	// node match
+	spans(d *document) []Span
+}
+
+// Span is the text of an entry field matched by a term
+type Span struct {
+	Field string `json:"field"`
+	Text  string `json:"text"`
+	Start int    `json:"start"` // Byte offset in the field text, without markup
+	End   int    `json:"end"`
+}
+
+// Parse compiles a filter pattern
+func Parse(pattern string) (*Expr, error) {
+	expression, ok := strings.CutPrefix(strings.TrimSpace(pattern), ExprPrefix)
+	if !ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return &Expr{source: pattern, root: &regexTerm{field: FieldAny, re: re}}, nil
+	}
+
+	tokens, err := lex(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %s", p.tokens[p.pos])
+	}
+	return &Expr{source: pattern, root: root}, nil
+}
+
+// Match reports whether an entry matches the pattern
+func (e *Expr) Match(entry cache.Entry) bool {
+	return e.root.match(&document{entry: &entry})
+}
+
+// Spans returns the text that made an entry match the pattern, or nil if it
+// does not match
+func (e *Expr) Spans(entry cache.Entry) []Span {
+	d := &document{entry: &entry}
+	if !e.root.match(d) {
+		return nil
+	}
+	return e.root.spans(d)
+}
+
+// String returns the pattern the expression was parsed from
+func (e *Expr) String() string {
+	return e.source
+}
+
+// document gives access to the fields of an entry, stripping markup once
+type document struct {
+	entry *cache.Entry
+
+	stripped bool
+	title    string
+	content  string
+}
+
+func (d *document) strip() {
+	if !d.stripped {
+		d.title = sanitizer.StripTags(d.entry.Title)
+		d.content = sanitizer.StripTags(d.entry.Content)
+		d.stripped = true
+	}
+}
+
+// values returns the texts of a field
+func (d *document) values(field string) []string {
+	switch field {
+	case FieldTitle:
+		d.strip()
+		return []string{d.title}
+	case FieldContent:
+		d.strip()
+		return []string{d.content}
+	case FieldAuthor:
+		return []string{d.entry.Author, d.entry.AuthorEmail}
+	case FieldCategory:
+		return d.entry.Categories
+	case FieldLink:
+		return []string{d.entry.Link}
+	case FieldFeed:
+		return []string{d.entry.ChannelName, d.entry.ChannelURL}
+	default:
+		d.strip()
+		return []string{d.title + " " + d.content}
+	}
+}
+
+// span returns the span of value[start:end], a match in one of the texts of
+// a field. Matches in the combined title and content are attributed to the
+// title or the content where possible.
+func (d *document) span(field, value string, start, end int) Span {
+	text := value[start:end]
+	if field == FieldAny {
+		switch offset := len(d.title) + 1; {
+		case end <= len(d.title):
+			field = FieldTitle
+		case start >= offset:
+			field = FieldContent
+			start -= offset
+			end -= offset
+		}
+	}
+	return Span{Field: field, Text: text, Start: start, End: end}
+}
+
+type orNode struct{ left, right node }
+
+func (n *orNode) match(d *document) bool { return n.left.match(d) || n.right.match(d) }
+
+func (n *orNode) spans(d *document) []Span {
+	var spans []Span
+	for _, operand := range []node{n.left, n.right} {
+		if operand.match(d) {
+			spans = append(spans, operand.spans(d)...)
+		}
+	}
+	return spans
+}
+
+type andNode struct{ left, right node }
+
+func (n *andNode) match(d *document) bool { return n.left.match(d) && n.right.match(d) }
+
+func (n *andNode) spans(d *document) []Span {
+	return append(n.left.spans(d), n.right.spans(d)...)
+}
+
+type notNode struct{ operand node }
+
+func (n *notNode) match(d *document) bool { return !n.operand.match(d) }
+
+// spans is empty: a NOT matches because of text that is absent
+func (n *notNode) spans(d *document) []Span { return nil }
+
+// regexTerm matches a field against a regular expression
+type regexTerm struct {
+	field string
+	re    *regexp.Regexp
+}
+
+func (t *regexTerm) match(d *document) bool {
+	for _, value := range d.values(t.field) {
+		if t.re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *regexTerm) spans(d *document) []Span {
+	for _, value := range d.values(t.field) {
+		if loc := t.re.FindStringIndex(value); loc != nil {
+			return []Span{d.span(t.field, value, loc[0], loc[1])}
+		}
+	}
+	return nil
+}
+
+// textTerm matches a field case-insensitively. Categories must be equal to
+// the text, other fields must contain it.
+type textTerm struct {
+	field string
+	text  string // Lowercased
+}
+
+func (t *textTerm) match(d *document) bool {
+	_, _, _, ok := t.find(d)
+	return ok
+}
+
+func (t *textTerm) spans(d *document) []Span {
+	if value, start, end, ok := t.find(d); ok {
+		return []Span{d.span(t.field, value, start, end)}
+	}
+	return nil
+}
+
+// find returns the first field text containing the term and the offsets of
+// the match
+func (t *textTerm) find(d *document) (string, int, int, bool) {
+	for _, value := range d.values(t.field) {
+		lower := strings.ToLower(value)
+		if len(lower) != len(value) {
+			value = lower // Offsets refer to the lowercased text
+		}
+		if t.field == FieldCategory {
+			if strings.TrimSpace(lower) == t.text {
+				return value, 0, len(value), true
+			}
+			continue
+		}
+		if i := strings.Index(lower, t.text); i >= 0 {
+			return value, i, i + len(t.text), true
+		}
+	}
+	return "", 0, 0, false
+}
+
+// Token kinds of the expression language
+const (
+	tokenTerm = iota
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind  int
+	text  string // Source text, for error messages
+	field string // Term field, FieldAny if none was given
+	value string // Term value, without quotes or slashes
+	regex bool   // Term value is a regular expression
+	flags string // Regular expression flags
+}
+
+func (t token) String() string {
+	return fmt.Sprintf("%q", t.text)
+}
+
+// lex splits an expression into tokens
+func lex(s string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")"})
+			i++
+		default:
+			tok, next, err := lexTerm(s, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = next
+		}
+	}
+	return tokens, nil
+}
+
+// lexTerm reads an operator or a term starting at s[start]
+func lexTerm(s string, start int) (token, int, error) {
+	tok := token{kind: tokenTerm, field: FieldAny}
+	i := start
+
+	// Optional field prefix
+	prefixed := false
+	if colon := strings.IndexByte(s[i:], ':'); colon > 0 && fields[s[i:i+colon]] {
+		tok.field = s[i : i+colon]
+		i += colon + 1
+		prefixed = true
+	}
+
+	switch {
+	case i < len(s) && s[i] == '/':
+		end := i + 1
+		var re strings.Builder
+		for ; end < len(s) && s[end] != '/'; end++ {
+			if s[end] == '\\' && end+1 < len(s) && s[end+1] == '/' {
+				end++ // Escaped slash
+			}
+			re.WriteByte(s[end])
+		}
+		if end >= len(s) {
+			return tok, 0, fmt.Errorf("unterminated regular expression in %q", s[start:])
+		}
+		end++
+		flagsStart := end
+		for end < len(s) && strings.IndexByte("ims", s[end]) >= 0 {
+			end++
+		}
+		if end < len(s) && !isDelimiter(s[end]) {
+			return tok, 0, fmt.Errorf("invalid regular expression flag %q (want i, m or s)", string(s[end]))
+		}
+		tok.regex = true
+		tok.value = re.String()
+		tok.flags = s[flagsStart:end]
+		i = end
+
+	case i < len(s) && s[i] == '"':
+		end := i + 1
+		var text strings.Builder
+		for ; end < len(s) && s[end] != '"'; end++ {
+			if s[end] == '\\' && end+1 < len(s) {
+				end++
+			}
+			text.WriteByte(s[end])
+		}
+		if end >= len(s) {
+			return tok, 0, fmt.Errorf("unterminated string in %q", s[start:])
+		}
+		tok.value = text.String()
+		i = end + 1
+
+	default:
+		end := i
+		for end < len(s) && !isDelimiter(s[end]) {
+			end++
+		}
+		tok.value = s[i:end]
+		i = end
+		if !prefixed {
+			switch tok.value {
+			case "AND":
+				tok.kind = tokenAnd
+			case "OR":
+				tok.kind = tokenOr
+			case "NOT":
+				tok.kind = tokenNot
+			}
+		}
+		if tok.kind == tokenTerm && tok.value == "" {
+			return tok, 0, fmt.Errorf("missing value after %q", s[start:i])
+		}
+	}
+
+	tok.text = s[start:i]
+	return tok, i, nil
+}
+
+func isDelimiter(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '(' || c == ')'
+}
+
+// parser builds an expression tree. Precedence from lowest to highest is
+// OR, AND, NOT; terms next to each other are joined with AND.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind != tokenOr {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left, right}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind == tokenOr || tok.kind == tokenClose {
+			return left, nil
+		}
+		if tok.kind == tokenAnd {
+			p.pos++
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left, right}
+	}
+}
+
+func (p *parser) parseNot() (node, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	switch tok.kind {
+	case tokenNot:
+		p.pos++
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand}, nil
+
+	case tokenOpen:
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok, ok := p.peek(); !ok || tok.kind != tokenClose {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return inner, nil
+
+	case tokenTerm:
+		p.pos++
+		return newTerm(tok)
+
+	default:
+		return nil, fmt.Errorf("unexpected %s", tok)
+	}
+}
+
+// newTerm compiles a term token
+func newTerm(tok token) (node, error) {
+	if !tok.regex {
+		return &textTerm{field: tok.field, text: strings.ToLower(tok.value)}, nil
+	}
+
+	pattern := tok.value
+	if tok.flags != "" {
+		pattern = "(?" + tok.flags + ")" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("term %s: %w", tok, err)
+	}
+	return &regexTerm{field: tok.field, re: re}, nil
+}
@@ -0,0 +1,124 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+)
+
+func TestParse_Match(t *testing.T) {
+	entry := cache.Entry{
+		Title:       "Learning <em>Clojure</em>",
+		Content:     `<p>Macros in <a href="https://python.org">Lisp</a></p>`,
+		Author:      "Alice",
+		AuthorEmail: "alice@example.com",
+		Categories:  []string{"Clojure", "FP"},
+		Link:        "https://blog.example.com/clojure-macros",
+		ChannelName: "Alice's Blog",
+		ChannelURL:  "https://blog.example.com/feed",
+	}
+
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		// Plain regexes keep working, but ignore markup
+		{"Clojure", true},
+		{"Learning Clojure", true},
+		{"Clojure|Rust", true},
+		{"python", false},
+		{"href", false},
+		{"^Learning", true},
+
+		{"expr: title:/clojure/i", true},
+		{"expr: title:/clojure/", false},
+		{"expr: title:clojure", true},
+		{`expr: title:"learning clojure"`, true},
+		{"expr: content:macros", true},
+		{"expr: content:python", false},
+		{`expr: author:"alice"`, true},
+		{"expr: author:example.com", true},
+		{"expr: author:bot", false},
+		{"expr: category:clojure", true},
+		{"expr: category:cloj", false},
+		{"expr: category:/^cloj/i", true},
+		{"expr: link:/clojure-macros$/", true},
+		{`expr: feed:"alice's blog"`, true},
+		{"expr: any:lisp", true},
+		{"expr: /lisp/i", true},
+
+		{`expr: title:/clojure/i AND NOT author:"bot" OR category:rust`, true},
+		{`expr: title:/clojure/i AND NOT author:"alice" OR category:rust`, false},
+		{`expr: title:/rust/i AND NOT author:"bot" OR category:fp`, true},
+		{`expr: title:/clojure/i AND NOT (author:alice OR category:rust)`, false},
+		{"expr: title:clojure content:macros", true},
+		{"expr: title:clojure content:python", false},
+		{"expr: NOT category:fp", false},
+		{`expr: "AND"`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			expr, err := Parse(tt.pattern)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.pattern, err)
+			}
+			if got := expr.Match(entry); got != tt.want {
+				t.Errorf("Parse(%q).Match() = %v, want %v", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, pattern := range []string{
+		"[invalid",
+		"expr:",
+		"expr: title:/unterminated",
+		`expr: title:"unterminated`,
+		"expr: title:/x/q",
+		"expr: title:/[x/",
+		"expr: title:x AND",
+		"expr: (title:x",
+		"expr: title:x)",
+		"expr: title: AND author:y",
+		"expr: NOT",
+	} {
+		if _, err := Parse(pattern); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", pattern)
+		}
+	}
+}
+
+func TestParse_LegacyRegex(t *testing.T) {
+	// Regexes that look like expressions keep matching as regexes
+	tests := []struct {
+		pattern string
+		title   string
+		want    bool
+	}{
+		{"Rust AND Go", "Rust AND Go", true},
+		{"Rust AND Go", "Rust", false},
+		{"cats OR dogs", "cats OR dogs", true},
+		{"cats OR dogs", "cats", false},
+		{"is NOT allowed", "This is NOT allowed", true},
+		{"title: .*", "Re: title: draft", true},
+		{"title:", "Untitled", false},
+		{"news (category:|tag:)", "news category: go", true},
+		{"/api/v1/", "Changes to /api/v1/ endpoints", true},
+		{"/api/v1/", "api v1", false},
+		{"/go/i", "/go/i", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.title, func(t *testing.T) {
+			expr, err := Parse(tt.pattern)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.pattern, err)
+			}
+			if got := expr.Match(cache.Entry{Title: tt.title}); got != tt.want {
+				t.Errorf("Parse(%q).Match(%q) = %v, want %v", tt.pattern, tt.title, got, tt.want)
+			}
+		})
+	}
+}
@@ -3,16 +3,16 @@ package filter
 import (
 	"fmt"
 	"log/slog"
-	"regexp"
+	"strings"
 
 	"github.com/alexey-ott/planet-go/internal/cache"
 	"github.com/alexey-ott/planet-go/internal/config"
 )
 
-// Filter applies regex-based filtering to entries
+// Filter applies include and exclude patterns (see Expr) to entries
 type Filter struct {
-	include *regexp.Regexp
-	exclude *regexp.Regexp
+	include *Expr
+	exclude *Expr
 }
 
 // New creates a new filter with include and exclude patterns
@@ -20,19 +20,19 @@ func New(includePattern, excludePattern string) (*Filter, error) {
 	f := &Filter{}
 
 	if includePattern != "" {
-		re, err := regexp.Compile(includePattern)
+		expr, err := Parse(includePattern)
 		if err != nil {
 			return nil, fmt.Errorf("compile include pattern: %w", err)
 		}
-		f.include = re
+		f.include = expr
 	}
 
 	if excludePattern != "" {
-		re, err := regexp.Compile(excludePattern)
+		expr, err := Parse(excludePattern)
 		if err != nil {
 			return nil, fmt.Errorf("compile exclude pattern: %w", err)
 		}
-		f.exclude = re
+		f.exclude = expr
 	}
 
 	return f, nil
@@ -47,43 +47,94 @@ func (f *Filter) Apply(entries []cache.Entry) []cache.Entry {
 	filtered := make([]cache.Entry, 0, len(entries))
 
 	for _, entry := range entries {
-		// Combine title and content for searching
-		text := entry.Title + " " + entry.Content
-
-		// Check include pattern
-		if f.include != nil && !f.include.MatchString(text) {
-			continue
+		if f.keep(entry) {
+			filtered = append(filtered, entry)
 		}
+	}
 
-		// Check exclude pattern
-		if f.exclude != nil && f.exclude.MatchString(text) {
-			continue
-		}
+	return filtered
+}
 
-		filtered = append(filtered, entry)
+// keep reports whether an entry passes the include and exclude patterns
+func (f *Filter) keep(entry cache.Entry) bool {
+	// Check include pattern
+	if f.include != nil && !f.include.Match(entry) {
+		return false
 	}
 
-	return filtered
+	// Check exclude pattern
+	return f.exclude == nil || !f.exclude.Match(entry)
 }
 
-// ApplyPerFeed filters entries using per-feed filters combined with global filters
-// Each entry is filtered using its feed's specific filter (if any) plus the global filter
-func ApplyPerFeed(entries []cache.Entry, feedConfigs []config.FeedConfig, globalInclude, globalExclude string) ([]cache.Entry, error) {
-	// Build a map of feed URL -> filter
-	feedFilters := make(map[string]*Filter)
+// String describes the filter's patterns
+func (f *Filter) String() string {
+	var parts []string
+	if f.include != nil {
+		parts = append(parts, fmt.Sprintf("filter %q", f.include))
+	}
+	if f.exclude != nil {
+		parts = append(parts, fmt.Sprintf("exclude %q", f.exclude))
+	}
+	return strings.Join(parts, " ")
+}
 
-	// Create filters for each feed
-	for _, feedConfig := range feedConfigs {
-		// Combine global and feed-level patterns
+// Modes for combining feed-level filter and exclude patterns with the global ones
+const (
+	ModeOverride = "override" // A feed's filter or exclude replaces the global one
+	ModeAnd      = "and"      // Entries must pass both the global and the feed's patterns
+	ModeOr       = "or"       // Entries must pass either the global or the feed's patterns
+)
+
+// rule is the effective filter of a feed. In override mode only feed is set.
+// A nil global or feed filter has no patterns and does not take part.
+type rule struct {
+	mode   string
+	global *Filter
+	feed   *Filter
+}
+
+func (r *rule) keep(entry cache.Entry) bool {
+	switch {
+	case r.global == nil:
+		return r.feed.keep(entry)
+	case r.feed == nil:
+		return r.global.keep(entry)
+	case r.mode == ModeOr:
+		return r.global.keep(entry) || r.feed.keep(entry)
+	default:
+		return r.global.keep(entry) && r.feed.keep(entry)
+	}
+}
+
+func (r *rule) String() string {
+	switch {
+	case r.global == nil:
+		return r.feed.String()
+	case r.feed == nil:
+		return r.global.String()
+	default:
+		return fmt.Sprintf("(%s) %s (%s)", r.global, strings.ToUpper(r.mode), r.feed)
+	}
+}
+
+// newRule builds a feed's effective filter from its patterns, the global
+// patterns and the filter mode. It returns nil if the feed is not filtered.
+func newRule(feedConfig config.FeedConfig, global *Filter, globalInclude, globalExclude, globalMode string) (*rule, error) {
+	mode := strings.ToLower(feedConfig.FilterMode(globalMode))
+	if mode == "" {
+		mode = ModeOverride
+	}
+	feedInclude := feedConfig.Filter()
+	feedExclude := feedConfig.Exclude()
+
+	switch mode {
+	case ModeOverride:
 		includePattern := globalInclude
 		excludePattern := globalExclude
 
-		feedFilter := feedConfig.Filter()
-		feedExclude := feedConfig.Exclude()
-
 		// If feed has its own filter, use it (feed-level overrides global)
-		if feedFilter != "" {
-			includePattern = feedFilter
+		if feedInclude != "" {
+			includePattern = feedInclude
 		}
 
 		// If feed has its own exclude, use it (feed-level overrides global)
@@ -92,18 +143,75 @@ func ApplyPerFeed(entries []cache.Entry, feedConfigs []config.FeedConfig, global
 		}
 
 		// Only create a filter if there's something to filter
-		if includePattern != "" || excludePattern != "" {
-			filter, err := New(includePattern, excludePattern)
+		if includePattern == "" && excludePattern == "" {
+			return nil, nil
+		}
+		filter, err := New(includePattern, excludePattern)
+		if err != nil {
+			return nil, err
+		}
+		return &rule{mode: mode, feed: filter}, nil
+
+	case ModeAnd, ModeOr:
+		r := &rule{mode: mode, global: global}
+		if feedInclude != "" || feedExclude != "" {
+			filter, err := New(feedInclude, feedExclude)
 			if err != nil {
-				return nil, fmt.Errorf("create filter for feed %s: %w", feedConfig.URL, err)
+				return nil, err
 			}
-			feedFilters[feedConfig.URL] = filter
+			r.feed = filter
+		}
+		if r.global == nil && r.feed == nil {
+			return nil, nil
+		}
+		return r, nil
+
+	default:
+		return nil, fmt.Errorf("unknown filter_mode %q (want %s, %s or %s)", mode, ModeOverride, ModeAnd, ModeOr)
+	}
+}
+
+// newRules returns the effective filters of the feeds that are filtered,
+// keyed by feed URL
+func newRules(feedConfigs []config.FeedConfig, globalInclude, globalExclude, globalMode string) (map[string]*rule, error) {
+	var global *Filter
+	if globalInclude != "" || globalExclude != "" {
+		var err error
+		global, err = New(globalInclude, globalExclude)
+		if err != nil {
+			return nil, fmt.Errorf("create global filter: %w", err)
+		}
+	}
 
-			slog.Debug("created per-feed filter",
+	feedRules := make(map[string]*rule)
+	for _, feedConfig := range feedConfigs {
+		r, err := newRule(feedConfig, global, globalInclude, globalExclude, globalMode)
+		if err != nil {
+			return nil, fmt.Errorf("create filter for feed %s: %w", feedConfig.URL, err)
+		}
+		if r != nil {
+			feedRules[feedConfig.URL] = r
+		}
+	}
+	return feedRules, nil
+}
+
+// ApplyPerFeed filters entries using per-feed filters combined with global
+// filters according to the filter mode (global filter_mode, overridable per
+// feed). Entries of feeds that are not configured are kept.
+func ApplyPerFeed(entries []cache.Entry, feedConfigs []config.FeedConfig, globalInclude, globalExclude, globalMode string) ([]cache.Entry, error) {
+	feedRules, err := newRules(feedConfigs, globalInclude, globalExclude, globalMode)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, feedConfig := range feedConfigs {
+		if r, ok := feedRules[feedConfig.URL]; ok {
+			slog.Debug("effective filter",
 				"feed", feedConfig.Name,
 				"url", feedConfig.URL,
-				"include", includePattern,
-				"exclude", excludePattern)
+				"mode", r.mode,
+				"rule", r.String())
 		}
 	}
 
@@ -113,24 +221,17 @@ func ApplyPerFeed(entries []cache.Entry, feedConfigs []config.FeedConfig, global
 
 	for _, entry := range entries {
 		// Find the filter for this entry's feed
-		filter, hasFilter := feedFilters[entry.ChannelURL]
+		r, hasRule := feedRules[entry.ChannelURL]
 
-		if !hasFilter {
-			// No filter for this feed, keep the entry
+		if !hasRule || r.keep(entry) {
 			filtered = append(filtered, entry)
 			continue
 		}
 
-		// Apply the filter
-		result := filter.Apply([]cache.Entry{entry})
-		if len(result) > 0 {
-			filtered = append(filtered, entry)
-		} else {
-			filteredCount++
-			slog.Debug("entry filtered out",
-				"feed", entry.ChannelName,
-				"title", entry.Title)
-		}
+		filteredCount++
+		slog.Debug("entry filtered out",
+			"feed", entry.ChannelName,
+			"title", entry.Title)
 	}
 
 	if filteredCount > 0 {
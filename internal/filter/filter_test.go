@@ -1,6 +1,7 @@
 package filter
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -124,7 +125,7 @@ func TestApplyPerFeed_FeedLevelFilter(t *testing.T) {
 		},
 	}
 
-	filtered, err := ApplyPerFeed(entries, feedConfigs, "", "")
+	filtered, err := ApplyPerFeed(entries, feedConfigs, "", "", ModeOverride)
 	if err != nil {
 		t.Fatalf("ApplyPerFeed() error = %v", err)
 	}
@@ -164,7 +165,7 @@ func TestApplyPerFeed_GlobalFilter(t *testing.T) {
 		},
 	}
 
-	filtered, err := ApplyPerFeed(entries, feedConfigs, "Clojure", "")
+	filtered, err := ApplyPerFeed(entries, feedConfigs, "Clojure", "", ModeOverride)
 	if err != nil {
 		t.Fatalf("ApplyPerFeed() error = %v", err)
 	}
@@ -203,7 +204,7 @@ func TestApplyPerFeed_FeedOverridesGlobal(t *testing.T) {
 		},
 	}
 
-	filtered, err := ApplyPerFeed(entries, feedConfigs, "Clojure", "")
+	filtered, err := ApplyPerFeed(entries, feedConfigs, "Clojure", "", ModeOverride)
 	if err != nil {
 		t.Fatalf("ApplyPerFeed() error = %v", err)
 	}
@@ -234,7 +235,7 @@ func TestApplyPerFeed_NoFilterForFeed(t *testing.T) {
 	}
 
 	// No global or feed-level filters
-	filtered, err := ApplyPerFeed(entries, feedConfigs, "", "")
+	filtered, err := ApplyPerFeed(entries, feedConfigs, "", "", ModeOverride)
 	if err != nil {
 		t.Fatalf("ApplyPerFeed() error = %v", err)
 	}
@@ -260,7 +261,7 @@ func TestApplyPerFeed_ExcludePattern(t *testing.T) {
 		},
 	}
 
-	filtered, err := ApplyPerFeed(entries, feedConfigs, "", "")
+	filtered, err := ApplyPerFeed(entries, feedConfigs, "", "", ModeOverride)
 	if err != nil {
 		t.Fatalf("ApplyPerFeed() error = %v", err)
 	}
@@ -273,3 +274,75 @@ func TestApplyPerFeed_ExcludePattern(t *testing.T) {
 		t.Errorf("filtered[0].Title = %q, want %q", filtered[0].Title, "Good Post")
 	}
 }
+
+func TestApplyPerFeed_FilterModes(t *testing.T) {
+	entries := []cache.Entry{
+		{Title: "Clojure Post", ChannelURL: "https://blog1.com/feed"},
+		{Title: "Clojure Spam", ChannelURL: "https://blog1.com/feed"},
+		{Title: "Clojure Job", ChannelURL: "https://blog1.com/feed"},
+		{Title: "Python Post", ChannelURL: "https://blog1.com/feed"},
+		{Title: "Rust Post", ChannelURL: "https://blog1.com/feed"},
+	}
+
+	tests := []struct {
+		name       string
+		globalMode string
+		extra      map[string]string
+		want       []string
+	}{
+		{
+			name:       "override replaces the global exclude",
+			globalMode: ModeOverride,
+			extra:      map[string]string{"exclude": "Job"},
+			want:       []string{"Clojure Post", "Clojure Spam"},
+		},
+		{
+			name:       "and keeps the global exclude",
+			globalMode: ModeAnd,
+			extra:      map[string]string{"exclude": "Job"},
+			want:       []string{"Clojure Post"},
+		},
+		{
+			name:       "or extends the global filter",
+			globalMode: ModeOr,
+			extra:      map[string]string{"filter": "Python"},
+			want:       []string{"Clojure Post", "Clojure Job", "Python Post"},
+		},
+		{
+			name:       "feed mode overrides the global mode",
+			globalMode: ModeOverride,
+			extra:      map[string]string{"filter_mode": "and", "filter": "Post"},
+			want:       []string{"Clojure Post"},
+		},
+		{
+			name:       "and without feed patterns uses the global ones",
+			globalMode: ModeAnd,
+			extra:      map[string]string{},
+			want:       []string{"Clojure Post", "Clojure Job"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feedConfigs := []config.FeedConfig{{URL: "https://blog1.com/feed", Name: "Blog 1", Extra: tt.extra}}
+
+			filtered, err := ApplyPerFeed(entries, feedConfigs, "Clojure", "Spam", tt.globalMode)
+			if err != nil {
+				t.Fatalf("ApplyPerFeed() error = %v", err)
+			}
+
+			var titles []string
+			for _, entry := range filtered {
+				titles = append(titles, entry.Title)
+			}
+			if strings.Join(titles, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("ApplyPerFeed() = %v, want %v", titles, tt.want)
+			}
+		})
+	}
+
+	feedConfigs := []config.FeedConfig{{URL: "https://blog1.com/feed", Extra: map[string]string{"filter_mode": "xor"}}}
+	if _, err := ApplyPerFeed(entries, feedConfigs, "Clojure", "", ModeOverride); err == nil {
+		t.Error("ApplyPerFeed() with an unknown filter_mode succeeded")
+	}
+}
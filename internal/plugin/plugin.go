@@ -0,0 +1,253 @@
+// Package plugin runs external filter programs on entries, like Venus
+// filters. A program gets one entry as JSON on stdin and writes the entry,
+// modified or not, as JSON to stdout. Writing nothing drops the entry.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+// DefaultTimeout is used when no timeout is configured
+const DefaultTimeout = 10 * time.Second
+
+// killDelay is how long to wait for the output pipes to close after a
+// program was killed, e.g. when a child process keeps them open
+const killDelay = time.Second
+
+// ErrNotStarted is returned for programs that cannot be started, e.g.
+// because they do not exist. Such programs are not tried again.
+var ErrNotStarted = errors.New("cannot start filter")
+
+// ResultDirectory is the directory in the cache directory that holds the
+// output of filter programs
+const ResultDirectory = "filters"
+
+// Runner runs filter programs. Failures are isolated: if a program fails,
+// times out or writes invalid output, the entry is passed on unchanged.
+// A Runner is not safe for concurrent use.
+type Runner struct {
+	timeout time.Duration
+	broken  map[string]error // Programs that could not be started
+	results *results         // Output of earlier runs, nil if not kept
+	runs    int              // Programs run, not counting kept output
+}
+
+// NewRunner creates a runner that stops programs after timeout
+func NewRunner(timeout time.Duration) *Runner {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Runner{timeout: timeout, broken: make(map[string]error)}
+}
+
+// SetResultDirectory keeps the output of programs in a directory, so that
+// an unchanged program is not run again for an unchanged entry
+func (r *Runner) SetResultDirectory(directory string) {
+	r.results = newResults(directory)
+}
+
+// Prune removes the kept output that was not used since the runner was
+// created, e.g. for entries that have expired from the cache
+func (r *Runner) Prune() error {
+	if r.results == nil {
+		return nil
+	}
+	return r.results.prune()
+}
+
+// parseFilter splits a filter into its program and arguments. Like in Venus,
+// arguments are given as a query string and passed as options, e.g.
+// "excerpt.py?width=500&omit=img+p" runs excerpt.py --width 500 --omit "img p".
+func parseFilter(filter string) (string, []string, error) {
+	program, query, found := strings.Cut(filter, "?")
+	if !found {
+		return program, nil, nil
+	}
+
+	var args []string
+	for _, param := range strings.Split(query, "&") {
+		if param == "" {
+			continue
+		}
+		key, value, hasValue := strings.Cut(param, "=")
+		key, err := url.QueryUnescape(key)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid argument %q: %w", param, err)
+		}
+		args = append(args, "--"+key)
+		if hasValue {
+			value, err := url.QueryUnescape(value)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid argument %q: %w", param, err)
+			}
+			args = append(args, value)
+		}
+	}
+	return program, args, nil
+}
+
+// Run pipes an entry through a filter program, given with its arguments as
+// in the filters option. It returns the entry written by the program and
+// false if the program dropped it.
+func (r *Runner) Run(filter string, entry cache.Entry) (cache.Entry, bool, error) {
+	if err, ok := r.broken[filter]; ok {
+		return entry, true, err
+	}
+
+	program, args, err := parseFilter(filter)
+	if err != nil {
+		r.broken[filter] = fmt.Errorf("%w: %v", ErrNotStarted, err)
+		return entry, true, r.broken[filter]
+	}
+
+	input, err := json.Marshal(entry)
+	if err != nil {
+		return entry, true, fmt.Errorf("marshal entry: %w", err)
+	}
+
+	key := r.results.key(filter, program, input)
+	if output, ok := r.results.load(key); ok {
+		return decodeOutput(entry, output)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, program, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = killDelay
+
+	r.runs++
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		switch {
+		case ctx.Err() != nil:
+			return entry, true, fmt.Errorf("timed out after %s", r.timeout)
+		case !errors.As(err, &exitErr):
+			// Not started at all, e.g. not found or not executable
+			r.broken[filter] = fmt.Errorf("%w: %v", ErrNotStarted, err)
+			return entry, true, r.broken[filter]
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return entry, true, fmt.Errorf("%w: %s", err, message)
+		}
+		return entry, true, err
+	}
+
+	output := bytes.TrimSpace(stdout.Bytes())
+	result, kept, err := decodeOutput(entry, output)
+	if err == nil {
+		if err := r.results.save(key, output); err != nil {
+			slog.Warn("failed to keep external filter output", "filter", filter, "error", err)
+		}
+	}
+	return result, kept, err
+}
+
+// decodeOutput decodes the output of a program. No output drops the entry.
+func decodeOutput(entry cache.Entry, output []byte) (cache.Entry, bool, error) {
+	if len(output) == 0 {
+		return entry, false, nil
+	}
+
+	var filtered cache.Entry
+	if err := json.Unmarshal(output, &filtered); err != nil {
+		return entry, true, fmt.Errorf("decode output: %w", err)
+	}
+	return filtered, true, nil
+}
+
+// ApplyPerFeed runs the global filter programs and then the feed's own
+// programs on every entry. Entries dropped by a program are removed. If
+// cacheDirectory is set, the output of the programs is kept there and only
+// new or changed entries are run through them.
+func ApplyPerFeed(entries []cache.Entry, feedConfigs []config.FeedConfig, global []string, timeout time.Duration, cacheDirectory string) []cache.Entry {
+	chains := make(map[string][]string, len(feedConfigs))
+	for _, feedConfig := range feedConfigs {
+		if programs := feedConfig.Filters(); len(programs) > 0 {
+			chains[feedConfig.URL] = append(append([]string{}, global...), programs...)
+		}
+	}
+	if len(global) == 0 && len(chains) == 0 {
+		return entries
+	}
+
+	runner := NewRunner(timeout)
+	if cacheDirectory != "" {
+		runner.SetResultDirectory(filepath.Join(cacheDirectory, ResultDirectory))
+	}
+	filtered := make([]cache.Entry, 0, len(entries))
+	dropped, failures := 0, 0
+	reported := make(map[string]bool) // Programs that cannot be started
+
+	for _, entry := range entries {
+		programs, ok := chains[entry.ChannelURL]
+		if !ok {
+			programs = global
+		}
+
+		keep := true
+		for _, program := range programs {
+			result, kept, err := runner.Run(program, entry)
+			if err != nil {
+				failures++
+				if errors.Is(err, ErrNotStarted) {
+					if !reported[program] {
+						reported[program] = true
+						slog.Error("cannot run external filter", "filter", program, "error", err)
+					}
+					continue
+				}
+				slog.Warn("external filter failed, entry passed on unchanged",
+					"filter", program,
+					"feed", entry.ChannelName,
+					"title", entry.Title,
+					"error", err)
+				continue
+			}
+			if !kept {
+				keep = false
+				slog.Debug("entry dropped by external filter",
+					"filter", program,
+					"feed", entry.ChannelName,
+					"title", entry.Title)
+				break
+			}
+			entry = result
+		}
+
+		if keep {
+			filtered = append(filtered, entry)
+		} else {
+			dropped++
+		}
+	}
+
+	if err := runner.Prune(); err != nil {
+		slog.Warn("failed to remove unused external filter output", "error", err)
+	}
+
+	slog.Info("external filters complete",
+		"entries", len(entries),
+		"runs", runner.runs,
+		"dropped", dropped,
+		"failures", failures)
+
+	return filtered
+}
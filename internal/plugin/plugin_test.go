@@ -0,0 +1,152 @@
+//go:build unix
+
+package plugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+// writeScript creates an executable shell script
+func writeScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunner_Run(t *testing.T) {
+	dir := t.TempDir()
+	rename := writeScript(t, dir, "rename.sh", `sed 's/"title":"[^"]*"/"title":"Renamed"/'`)
+	drop := writeScript(t, dir, "drop.sh", `cat >/dev/null`)
+	fail := writeScript(t, dir, "fail.sh", `echo "broken feed" >&2; exit 3`)
+	invalid := writeScript(t, dir, "invalid.sh", `cat >/dev/null; echo "not json"`)
+	slow := writeScript(t, dir, "slow.sh", `exec sleep 5`)
+
+	runner := NewRunner(200 * time.Millisecond)
+	entry := cache.Entry{ID: "1", Title: "Original", Content: "<p>Body</p>"}
+
+	result, kept, err := runner.Run(rename, entry)
+	if err != nil || !kept {
+		t.Fatalf("Run(rename) = %v, %v, want kept", kept, err)
+	}
+	if result.Title != "Renamed" || result.Content != entry.Content {
+		t.Errorf("Run(rename) = %+v, want the title renamed", result)
+	}
+
+	if _, kept, err := runner.Run(drop, entry); err != nil || kept {
+		t.Errorf("Run(drop) = %v, %v, want dropped", kept, err)
+	}
+
+	for _, program := range []string{fail, invalid, slow} {
+		result, kept, err := runner.Run(program, entry)
+		if err == nil {
+			t.Errorf("Run(%s) succeeded, want error", filepath.Base(program))
+		}
+		if !kept || result.Title != entry.Title {
+			t.Errorf("Run(%s) = %+v, %v, want the entry unchanged", filepath.Base(program), result, kept)
+		}
+	}
+
+	// Arguments are passed as options
+	args := writeScript(t, dir, "args.sh", `sed "s/\"title\":\"[^\"]*\"/\"title\":\"$*\"/"`)
+	result, _, err = runner.Run(args+"?prefix=Re:+&verbose", entry)
+	if err != nil {
+		t.Fatalf("Run(args) error = %v", err)
+	}
+	if result.Title != "--prefix Re:  --verbose" {
+		t.Errorf("Run(args) title = %q, want the arguments", result.Title)
+	}
+	if _, _, err := runner.Run(args+"?width=%zz", entry); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("Run() with invalid arguments error = %v, want ErrNotStarted", err)
+	}
+
+	missing := filepath.Join(dir, "missing.sh")
+	if _, _, err := runner.Run(missing, entry); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("Run(missing) error = %v, want ErrNotStarted", err)
+	}
+}
+
+func TestApplyPerFeed(t *testing.T) {
+	dir := t.TempDir()
+	rename := writeScript(t, dir, "rename.sh", `sed 's/"title":"[^"]*"/"title":"Renamed"/'`)
+	dropSpam := writeScript(t, dir, "drop-spam.sh", `input=$(cat); case "$input" in *Spam*) ;; *) echo "$input" ;; esac`)
+	fail := writeScript(t, dir, "fail.sh", `exit 1`)
+
+	entries := []cache.Entry{
+		{Title: "Post", ChannelURL: "https://blog1.com/feed"},
+		{Title: "Spam", ChannelURL: "https://blog1.com/feed"},
+		{Title: "Spam", ChannelURL: "https://blog2.com/feed"},
+	}
+	feedConfigs := []config.FeedConfig{
+		{URL: "https://blog1.com/feed", Extra: map[string]string{"filters": fail + " " + rename}},
+		{URL: "https://blog2.com/feed", Extra: map[string]string{}},
+	}
+
+	// The global filter runs first, then the feed's filters; a failing
+	// filter does not stop the chain
+	filtered := ApplyPerFeed(entries, feedConfigs, []string{dropSpam}, time.Second, "")
+	if len(filtered) != 1 {
+		t.Fatalf("len(ApplyPerFeed()) = %d, want 1", len(filtered))
+	}
+	if filtered[0].Title != "Renamed" {
+		t.Errorf("ApplyPerFeed()[0].Title = %q, want %q", filtered[0].Title, "Renamed")
+	}
+
+	if got := ApplyPerFeed(entries, feedConfigs[1:], nil, time.Second, ""); len(got) != len(entries) {
+		t.Errorf("len(ApplyPerFeed()) without filters = %d, want %d", len(got), len(entries))
+	}
+}
+
+func TestApplyPerFeed_KeepsResults(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := filepath.Join(dir, "cache")
+	runs := filepath.Join(dir, "runs")
+	count := writeScript(t, dir, "count.sh", `echo run >>`+runs+`; cat`)
+
+	countRuns := func() int {
+		data, err := os.ReadFile(runs)
+		if err != nil {
+			return 0
+		}
+		return strings.Count(string(data), "run")
+	}
+
+	entries := []cache.Entry{
+		{Title: "First", ChannelURL: "https://blog.com/feed"},
+		{Title: "Second", ChannelURL: "https://blog.com/feed"},
+	}
+	global := []string{count}
+
+	ApplyPerFeed(entries, nil, global, time.Second, cacheDir)
+	if got := countRuns(); got != 2 {
+		t.Fatalf("first render ran the filter %d times, want 2", got)
+	}
+
+	// Only the new entry is run; the output for the expired one is removed
+	entries = []cache.Entry{entries[1], {Title: "Third", ChannelURL: "https://blog.com/feed"}}
+	filtered := ApplyPerFeed(entries, nil, global, time.Second, cacheDir)
+	if got := countRuns(); got != 3 {
+		t.Errorf("second render ran the filter %d times in total, want 3", got)
+	}
+	if len(filtered) != 2 || filtered[0].Title != "Second" || filtered[1].Title != "Third" {
+		t.Errorf("ApplyPerFeed() = %+v, want the entries unchanged", filtered)
+	}
+
+	kept, err := filepath.Glob(filepath.Join(cacheDir, ResultDirectory, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) != 2 {
+		t.Errorf("kept output for %d entries, want 2", len(kept))
+	}
+}
@@ -0,0 +1,96 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexey-ott/planet-go/internal/atomicfile"
+)
+
+// results keeps the output of filter programs, one file per program and
+// input. A nil *results keeps nothing.
+type results struct {
+	directory string
+	used      map[string]bool // Keys loaded or saved by this run
+}
+
+func newResults(directory string) *results {
+	return &results{directory: directory, used: make(map[string]bool)}
+}
+
+// key identifies the output of a filter for an input. The program file's
+// size and modification time are part of the key, so that output is not
+// reused after the program changed. It returns "" if the program cannot be
+// found.
+func (r *results) key(filter, program string, input []byte) string {
+	if r == nil {
+		return ""
+	}
+	path, err := exec.LookPath(program)
+	if err != nil {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%d\x00%d\x00", filter, path, info.Size(), info.ModTime().UnixNano())
+	h.Write(input)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (r *results) path(key string) string {
+	return filepath.Join(r.directory, key+".json")
+}
+
+// load returns the kept output for a key
+func (r *results) load(key string) ([]byte, bool) {
+	if r == nil || key == "" {
+		return nil, false
+	}
+	output, err := os.ReadFile(r.path(key))
+	if err != nil {
+		return nil, false
+	}
+	r.used[key] = true
+	return output, true
+}
+
+// save keeps the output for a key
+func (r *results) save(key string, output []byte) error {
+	if r == nil || key == "" {
+		return nil
+	}
+	if err := os.MkdirAll(r.directory, 0755); err != nil {
+		return fmt.Errorf("create filter output directory: %w", err)
+	}
+	if err := atomicfile.WriteFile(r.path(key), output, 0644); err != nil {
+		return err
+	}
+	r.used[key] = true
+	return nil
+}
+
+// prune removes the output not loaded or saved by this run
+func (r *results) prune() error {
+	paths, err := filepath.Glob(filepath.Join(r.directory, "*.json"))
+	if err != nil {
+		return fmt.Errorf("glob filter output: %w", err)
+	}
+	for _, path := range paths {
+		if r.used[strings.TrimSuffix(filepath.Base(path), ".json")] {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("remove filter output: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package renderer
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// Atom 1.0 (RFC 4287) document structure
+
+type atomFeed struct {
+	XMLName   xml.Name      `xml:"http://www.w3.org/2005/Atom feed"`
+	Title     string        `xml:"title"`
+	Links     []atomLink    `xml:"link"`
+	ID        string        `xml:"id"`
+	Updated   string        `xml:"updated"`
+	Author    *atomPerson   `xml:"author,omitempty"`
+	Generator atomGenerator `xml:"generator"`
+	Entries   []atomEntry   `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email,omitempty"`
+	URI   string `xml:"uri,omitempty"`
+}
+
+type atomGenerator struct {
+	URI  string `xml:"uri,attr"`
+	Name string `xml:",chardata"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Lang string `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	Lang    string      `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+	Title   atomText    `xml:"title"`
+	Links   []atomLink  `xml:"link"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomPerson  `xml:"author"`
+	Summary *atomText   `xml:"summary,omitempty"`
+	Content atomText    `xml:"content"`
+	Source  *atomSource `xml:"source,omitempty"`
+}
+
+// atomSource preserves the metadata of the feed an entry was aggregated from
+type atomSource struct {
+	ID       string      `xml:"id,omitempty"`
+	Title    *atomText   `xml:"title,omitempty"`
+	Subtitle *atomText   `xml:"subtitle,omitempty"`
+	Links    []atomLink  `xml:"link"`
+	Updated  string      `xml:"updated,omitempty"`
+	Author   *atomPerson `xml:"author,omitempty"`
+	Rights   *atomText   `xml:"rights,omitempty"`
+}
+
+// writeAtom writes an Atom 1.0 feed of the entries, each with an atom:source
+// element describing its original feed
+func writeAtom(w io.Writer, data TemplateData) error {
+	self := feedURL(data, atomFile)
+	feed := atomFeed{
+		Title:     data.Name,
+		Links:     []atomLink{{Rel: "self", Type: "application/atom+xml", Href: self}},
+		ID:        self,
+		Updated:   data.DateISO,
+		Generator: atomGenerator{URI: generatorURI, Name: data.Generator},
+		Entries:   make([]atomEntry, 0, len(data.Items)),
+	}
+	if data.Link != "" {
+		feed.Links = append(feed.Links, atomLink{Rel: "alternate", Type: "text/html", Href: data.Link})
+	}
+	if data.OwnerName != "" {
+		feed.Author = &atomPerson{Name: data.OwnerName, Email: data.OwnerEmail}
+	}
+
+	for _, item := range data.Items {
+		entry := atomEntry{
+			Lang:    item.ChannelLanguage,
+			Title:   atomText{Lang: item.TitleLanguage, Body: item.Title},
+			ID:      item.ID,
+			Updated: item.DateISO,
+			Author:  entryAuthor(item),
+			Content: atomText{Type: "html", Lang: item.ContentLanguage, Body: string(item.FullContent)},
+			Source:  entrySource(item),
+		}
+		if entry.Updated == "" {
+			// updated is required; entries without a date count as updated now
+			entry.Updated = data.DateISO
+		}
+		if item.Link != "" {
+			entry.Links = []atomLink{{Rel: "alternate", Type: "text/html", Href: item.Link}}
+		}
+		if item.Truncated {
+			entry.Summary = &atomText{Type: "html", Lang: item.ContentLanguage, Body: string(item.Excerpt)}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return writeXML(w, feed)
+}
+
+// entryAuthor returns the entry's author, falling back to the feed's author
+// and then to the feed name
+func entryAuthor(item TemplateEntry) atomPerson {
+	author := atomPerson{Name: item.Author, Email: item.AuthorEmail, URI: item.ChannelLink}
+	if author.Name == "" {
+		author.Name, author.Email = item.ChannelAuthorName, item.ChannelAuthorEmail
+	}
+	if author.Name == "" {
+		author.Name = item.ChannelName
+	}
+	return author
+}
+
+// entrySource returns the atom:source element for the entry's feed
+func entrySource(item TemplateEntry) *atomSource {
+	source := &atomSource{
+		ID:      item.ChannelID,
+		Updated: item.ChannelUpdatedISO,
+	}
+	if source.ID == "" {
+		source.ID = item.ChannelURL
+	}
+	if title := channelTitle(item); title != "" {
+		source.Title = &atomText{Body: title}
+	}
+	if item.ChannelSubtitle != "" {
+		source.Subtitle = &atomText{Body: item.ChannelSubtitle}
+	}
+	if item.ChannelURL != "" {
+		source.Links = append(source.Links, atomLink{Rel: "self", Href: item.ChannelURL})
+	}
+	if item.ChannelLink != "" {
+		source.Links = append(source.Links, atomLink{Rel: "alternate", Type: "text/html", Href: item.ChannelLink})
+	}
+	if item.ChannelAuthorName != "" {
+		source.Author = &atomPerson{Name: item.ChannelAuthorName, Email: item.ChannelAuthorEmail}
+	}
+	if item.ChannelRights != "" {
+		source.Rights = &atomText{Body: item.ChannelRights}
+	}
+	return source
+}
@@ -0,0 +1,164 @@
+package renderer
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+)
+
+// Template engines
+const (
+	EngineHTML = "html" // html/template, contextual HTML escaping
+	EngineXML  = "xml"  // text/template, every action XML-escaped
+	EngineText = "text" // text/template, no escaping
+)
+
+// xmlExtensions are outputs rendered with the XML engine by default
+var xmlExtensions = map[string]bool{
+	".xml": true, ".atom": true, ".rss": true, ".rdf": true, ".opml": true,
+}
+
+// executor is a parsed template of either engine
+type executor interface {
+	Execute(w io.Writer, data any) error
+}
+
+// engineFor returns the engine for an output file: the configured one, or
+// else one picked by the file extension
+func engineFor(outputName, configured string) string {
+	if configured != "" {
+		return configured
+	}
+	switch ext := strings.ToLower(filepath.Ext(outputName)); {
+	case xmlExtensions[ext]:
+		return EngineXML
+	case ext == ".txt":
+		return EngineText
+	default:
+		return EngineHTML
+	}
+}
+
+// parseTemplate parses a template file with the given engine and the
+// template functions
+func parseTemplate(path, engine string) (executor, error) {
+	switch engine {
+	case EngineHTML:
+		return htmltemplate.New(filepath.Base(path)).Funcs(funcs).ParseFiles(path)
+	case EngineText:
+		return texttemplate.New(filepath.Base(path)).Funcs(funcs).ParseFiles(path)
+	case EngineXML:
+		tmpl, err := texttemplate.New(filepath.Base(path)).Funcs(funcs).Funcs(xmlFuncs).ParseFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tmpl.Templates() {
+			if t.Tree != nil {
+				escapeActions(t.Tree.Root)
+			}
+		}
+		return tmpl, nil
+	default:
+		return nil, fmt.Errorf("unknown template engine %q", engine)
+	}
+}
+
+// xmlFuncs are available in XML templates. Actions ending in xml or raw are
+// not escaped again; raw writes its argument as is.
+var xmlFuncs = texttemplate.FuncMap{
+	"xml": xmlEscaper,
+	"raw": fmt.Sprint,
+}
+
+// escapeActions appends the xml escaper to every action that writes output.
+// Branch conditions and variable declarations write nothing, and invoked
+// templates escape their own actions.
+func escapeActions(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			escapeActions(child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) > 0 || escaped(n.Pipe) {
+			return
+		}
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      n.Pos,
+			Args:     []parse.Node{parse.NewIdentifier("xml").SetPos(n.Pos)},
+		})
+	case *parse.IfNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	case *parse.RangeNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	case *parse.WithNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	}
+}
+
+// escaped reports whether a pipeline already ends in xml or raw
+func escaped(pipe *parse.PipeNode) bool {
+	if len(pipe.Cmds) == 0 {
+		return false
+	}
+	last := pipe.Cmds[len(pipe.Cmds)-1]
+	if ident, ok := last.Args[0].(*parse.IdentifierNode); ok {
+		return ident.Ident == "xml" || ident.Ident == "raw"
+	}
+	return false
+}
+
+// xmlEscaper formats its arguments like print and escapes the result for XML
+// text and attribute values
+func xmlEscaper(args ...any) string {
+	return escapeXML(fmt.Sprint(args...))
+}
+
+// escapeXML escapes a string for XML text and attribute values. Characters
+// not allowed in XML 1.0 are dropped.
+func escapeXML(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range strings.ToValidUTF8(s, "") {
+		switch r {
+		case '&':
+			b.WriteString("&amp;")
+		case '<':
+			b.WriteString("&lt;")
+		case '>':
+			b.WriteString("&gt;")
+		case '"':
+			b.WriteString("&#34;")
+		case '\'':
+			b.WriteString("&#39;")
+		default:
+			if validXMLChar(r) {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+// validXMLChar reports whether r is allowed in an XML 1.0 document
+func validXMLChar(r rune) bool {
+	switch {
+	case r == '\t' || r == '\n' || r == '\r':
+		return true
+	case r < 0x20, r == 0xFFFE, r == 0xFFFF:
+		return false
+	default:
+		return true
+	}
+}
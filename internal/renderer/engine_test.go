@@ -0,0 +1,151 @@
+package renderer
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+func TestEngineFor(t *testing.T) {
+	tests := []struct {
+		outputName, configured, want string
+	}{
+		{"index.html", "", EngineHTML},
+		{"atom.xml", "", EngineXML},
+		{"feed.RSS", "", EngineXML},
+		{"opml.opml", "", EngineXML},
+		{"robots.txt", "", EngineText},
+		{"feed.json", "", EngineHTML},
+		{"atom.xml", EngineHTML, EngineHTML},
+		{"index.html", EngineText, EngineText},
+	}
+	for _, tt := range tests {
+		if got := engineFor(tt.outputName, tt.configured); got != tt.want {
+			t.Errorf("engineFor(%q, %q) = %q, want %q", tt.outputName, tt.configured, got, tt.want)
+		}
+	}
+}
+
+func TestRenderer_XMLEscaping(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputDir := filepath.Join(tmpDir, "output")
+	tmplPath := filepath.Join(tmpDir, "atom.xml.tmpl")
+
+	tmplContent := `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+<title>{{.Name}}</title>
+{{range .Items}}{{$link := .Link}}<entry>
+<title type="html">{{.Title}}</title>
+<link href="{{$link}}"/>
+{{with .Author}}<author><name>{{.}}</name></author>{{end}}
+<content type="html">{{.Content}}</content>
+{{template "source" .}}</entry>
+{{end}}{{"<!-- raw -->" | raw}}
+</feed>
+{{define "source"}}<source><title>{{.ChannelTitle}}</title></source>{{end}}`
+	if err := os.WriteFile(tmplPath, []byte(tmplContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		Planet: config.PlanetConfig{
+			Name:         "Tom & Jerry's <Planet>",
+			ItemsPerPage: 10,
+			DateFormat:   "2006-01-02",
+		},
+	}
+	entries := []cache.Entry{{
+		Title:        "Generics < Interfaces",
+		Link:         "https://example.com/post?a=1&b=2",
+		Author:       `"Bob"`,
+		Date:         time.Now(),
+		Content:      `<p>Fish &amp; chips <a href="/x?a=1&amp;b=2">link</a></p>` + "\x0b",
+		ChannelTitle: "Bob's Blog",
+	}}
+
+	if err := New(outputDir).Render(tmplPath, entries, cfg); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "atom.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "<!-- raw -->") {
+		t.Errorf("raw output was escaped:\n%s", content)
+	}
+
+	var feed struct {
+		Title string `xml:"title"`
+		Entry struct {
+			Title string `xml:"title"`
+			Link  struct {
+				Href string `xml:"href,attr"`
+			} `xml:"link"`
+			Author  string `xml:"author>name"`
+			Content string `xml:"content"`
+			Source  string `xml:"source>title"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(content, &feed); err != nil {
+		t.Fatalf("output is not well-formed XML: %v\n%s", err, content)
+	}
+
+	checks := []struct{ field, got, want string }{
+		{"feed title", feed.Title, cfg.Planet.Name},
+		{"entry title", feed.Entry.Title, entries[0].Title},
+		{"link", feed.Entry.Link.Href, entries[0].Link},
+		{"author", feed.Entry.Author, entries[0].Author},
+		{"content", feed.Entry.Content, `<p>Fish &amp; chips <a href="/x?a=1&amp;b=2">link</a></p>`},
+		{"source", feed.Entry.Source, entries[0].ChannelTitle},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %q, want %q", c.field, c.got, c.want)
+		}
+	}
+}
+
+func TestRenderer_Engine(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputDir := filepath.Join(tmpDir, "output")
+	tmplPath := filepath.Join(tmpDir, "notes.txt.tmpl")
+
+	if err := os.WriteFile(tmplPath, []byte(`{{range .Items}}{{.Title}}{{end}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{Planet: config.PlanetConfig{ItemsPerPage: 10}}
+	entries := []cache.Entry{{Title: "Q&A <live>", Date: time.Now()}}
+
+	render := func() string {
+		t.Helper()
+		if err := New(outputDir).Render(tmplPath, entries, cfg); err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		content, err := os.ReadFile(filepath.Join(outputDir, "notes.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+
+	if got := render(); got != "Q&A <live>" {
+		t.Errorf("text engine output = %q", got)
+	}
+
+	cfg.Templates = map[string]config.TemplateConfig{tmplPath: {Engine: EngineHTML}}
+	if got := render(); got != "Q&amp;A &lt;live&gt;" {
+		t.Errorf("html engine output = %q", got)
+	}
+
+	cfg.Templates[tmplPath] = config.TemplateConfig{Engine: "jinja"}
+	if err := New(outputDir).Render(tmplPath, entries, cfg); err == nil {
+		t.Error("Render() with an unknown engine succeeded")
+	}
+}
@@ -0,0 +1,146 @@
+package renderer
+
+import (
+	"html"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	nethtml "golang.org/x/net/html"
+
+	"github.com/alexey-ott/planet-go/internal/sanitizer"
+)
+
+// wordsPerMinute is the reading speed used for ReadingTime
+const wordsPerMinute = 200
+
+// ellipsis is appended to cut excerpts
+const ellipsis = "…"
+
+// voidElements never have an end tag
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true,
+	"img": true, "input": true, "link": true, "meta": true, "source": true,
+	"track": true, "wbr": true,
+}
+
+// excerpt cuts an HTML fragment after maxWords words or maxChars characters
+// of text, whichever comes first (0 disables a limit). The cut is made
+// between words and never inside a tag, and elements still open at the cut
+// are closed. It reports whether anything was cut.
+func excerpt(content string, maxWords, maxChars int) (string, bool) {
+	if content == "" || (maxWords <= 0 && maxChars <= 0) {
+		return content, false
+	}
+
+	var out strings.Builder
+	var open []string // Elements opened in the excerpt, not yet closed
+	words, chars := 0, 0
+
+	// End of the last text written and the elements open there. A cut before
+	// the first word of a text goes back to it, dropping the markup between.
+	lastText, lastOpen := 0, []string(nil)
+
+	z := nethtml.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		switch tt {
+		case nethtml.ErrorToken:
+			// Reached the end without hitting a limit
+			return content, false
+
+		case nethtml.TextToken:
+			text := string(z.Text())
+			end, complete := fitText(text, &words, &chars, maxWords, maxChars)
+			if complete {
+				out.Write(z.Raw())
+				if strings.TrimSpace(text) != "" {
+					lastText, lastOpen = out.Len(), slices.Clone(open)
+				}
+				continue
+			}
+
+			var cut string
+			if kept := strings.TrimRightFunc(text[:end], unicode.IsSpace); kept != "" {
+				cut = out.String() + html.EscapeString(kept)
+			} else {
+				cut = strings.TrimRightFunc(out.String()[:lastText], unicode.IsSpace)
+				open = lastOpen
+			}
+			cut += ellipsis
+			for i := len(open) - 1; i >= 0; i-- {
+				cut += "</" + open[i] + ">"
+			}
+			return cut, true
+
+		case nethtml.StartTagToken:
+			out.Write(z.Raw())
+			name, _ := z.TagName()
+			if tag := string(name); !voidElements[tag] {
+				open = append(open, tag)
+			}
+
+		case nethtml.EndTagToken:
+			out.Write(z.Raw())
+			name, _ := z.TagName()
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == string(name) {
+					open = open[:i]
+					break
+				}
+			}
+
+		default:
+			out.Write(z.Raw())
+		}
+	}
+}
+
+// fitText counts the words of text against the limits. It returns true if
+// the whole text fits, or else the length of the text that fits.
+func fitText(text string, words, chars *int, maxWords, maxChars int) (int, bool) {
+	start := *chars
+	i := 0
+	for i < len(text) {
+		// Skip to the start of the next word
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+
+		end := i
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if unicode.IsSpace(r) {
+				break
+			}
+			end += size
+		}
+
+		if maxWords > 0 && *words >= maxWords {
+			return i, false
+		}
+		if maxChars > 0 && start+utf8.RuneCountInString(text[:end]) > maxChars {
+			return i, false
+		}
+		*words++
+		i = end
+	}
+	*chars = start + utf8.RuneCountInString(text)
+	return len(text), true
+}
+
+// wordCount returns the number of words in the text of an HTML fragment
+func wordCount(content string) int {
+	return len(strings.Fields(sanitizer.StripTags(content)))
+}
+
+// readingTime returns the minutes needed to read words, at least 1 for any text
+func readingTime(words int) int {
+	if words == 0 {
+		return 0
+	}
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
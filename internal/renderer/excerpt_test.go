@@ -0,0 +1,100 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		words     int
+		chars     int
+		want      string
+		truncated bool
+	}{
+		{
+			name:    "short content is kept",
+			content: "<p>One two three</p>",
+			words:   5,
+			want:    "<p>One two three</p>",
+		},
+		{
+			name:      "word limit closes open elements",
+			content:   `<p>One <a href="/x">two <em>three four</em></a> five</p><p>six</p>`,
+			words:     3,
+			want:      `<p>One <a href="/x">two <em>three…</em></a></p>`,
+			truncated: true,
+		},
+		{
+			name:      "cut between paragraphs",
+			content:   "<p>One two</p>\n<p>three four</p>",
+			words:     2,
+			want:      "<p>One two…</p>",
+			truncated: true,
+		},
+		{
+			name:      "markup after the last word is dropped",
+			content:   "<p>Fish <b>and</b> chips</p>",
+			words:     1,
+			want:      "<p>Fish…</p>",
+			truncated: true,
+		},
+		{
+			name:      "character limit does not split words",
+			content:   "<p>Hello wonderful world</p>",
+			chars:     12,
+			want:      "<p>Hello…</p>",
+			truncated: true,
+		},
+		{
+			name:      "void elements are not closed",
+			content:   `<p>One<br>two <img src="a.png"> three</p>`,
+			words:     2,
+			want:      `<p>One<br>two…</p>`,
+			truncated: true,
+		},
+		{
+			name:      "entities stay escaped",
+			content:   "<p>Fish &amp; chips &lt;3 for dinner</p>",
+			words:     3,
+			want:      "<p>Fish &amp; chips…</p>",
+			truncated: true,
+		},
+		{
+			name:      "plain text",
+			content:   "one two three",
+			words:     1,
+			want:      "one…",
+			truncated: true,
+		},
+		{
+			name:    "no limits",
+			content: "<p>One two three</p>",
+			want:    "<p>One two three</p>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := excerpt(tt.content, tt.words, tt.chars)
+			if got != tt.want || truncated != tt.truncated {
+				t.Errorf("excerpt() = %q, %v, want %q, %v", got, truncated, tt.want, tt.truncated)
+			}
+		})
+	}
+}
+
+func TestWordCount_ReadingTime(t *testing.T) {
+	content := "<p>" + strings.Repeat("word ", 450) + "</p><p>the <b>end</b></p>"
+	if got := wordCount(content); got != 452 {
+		t.Errorf("wordCount() = %d, want 452", got)
+	}
+
+	for words, want := range map[int]int{0: 0, 1: 1, 200: 1, 201: 2, 452: 3} {
+		if got := readingTime(words); got != want {
+			t.Errorf("readingTime(%d) = %d, want %d", words, got, want)
+		}
+	}
+}
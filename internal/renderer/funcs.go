@@ -0,0 +1,274 @@
+package renderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/alexey-ott/planet-go/internal/config"
+	"github.com/alexey-ott/planet-go/internal/sanitizer"
+)
+
+// funcs are available in templates of every engine. Functions taking a
+// value as their last argument can be used in pipelines, e.g.
+// {{.Title | truncate 40}}.
+var funcs = map[string]any{
+	"striptags":  striptags,
+	"truncate":   truncate,
+	"excerpt":    excerptFunc,
+	"timeago":    timeago,
+	"formatDate": formatDate,
+	"urlquery":   urlquery,
+	"lower":      lower,
+	"slugify":    slugify,
+	"first":      first,
+	"groupBy":    groupBy,
+	"json":       toJSON,
+}
+
+// Group is a run of items sharing a key, as returned by groupBy
+type Group struct {
+	Key   string
+	Items []any
+}
+
+// toString returns the string form of a template value
+func toString(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case htmltemplate.HTML:
+		return string(s)
+	case fmt.Stringer:
+		return s.String()
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// striptags returns the text of an HTML fragment without markup, with
+// entities decoded
+func striptags(v any) string {
+	return sanitizer.StripTags(toString(v))
+}
+
+// truncate shortens a plain text to at most n characters, cutting at the last
+// word boundary and appending an ellipsis
+func truncate(n int, v any) string {
+	s := toString(v)
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	// Leave room for the ellipsis
+	cut := []rune(s)[:max(n-1, 0)]
+	if i := strings.LastIndexFunc(string(cut), unicode.IsSpace); i > 0 {
+		return strings.TrimRightFunc(string(cut)[:i], unicode.IsSpace) + ellipsis
+	}
+	return string(cut) + ellipsis
+}
+
+// excerptFunc cuts an HTML fragment such as .Content after n words, closing
+// open elements. Only template.HTML values are treated as markup; anything
+// else is plain text and escaped first.
+func excerptFunc(n int, v any) htmltemplate.HTML {
+	content, ok := v.(htmltemplate.HTML)
+	if !ok {
+		content = htmltemplate.HTML(html.EscapeString(toString(v)))
+	}
+	cut, _ := excerpt(string(content), n, 0)
+	return htmltemplate.HTML(cut)
+}
+
+// timeago describes a time relative to now, e.g. "3 hours ago". It takes a
+// time.Time or an RFC 3339 date such as .DateISO; other values give "".
+func timeago(v any) string {
+	t, ok := toTime(v)
+	if !ok {
+		return ""
+	}
+
+	d := time.Since(t)
+	suffix := " ago"
+	if d < 0 {
+		d, suffix = -d, " from now"
+	}
+
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute") + suffix
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour") + suffix
+	case d < 30*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day") + suffix
+	case d < 365*24*time.Hour:
+		return plural(int(d/(30*24*time.Hour)), "month") + suffix
+	default:
+		return plural(int(d/(365*24*time.Hour)), "year") + suffix
+	}
+}
+
+// plural formats a count of units, e.g. "1 hour" or "3 hours"
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// formatDate formats a time.Time or RFC 3339 date with a strftime-style
+// format ("%d %B %Y") or a Go layout ("2 January 2006")
+func formatDate(layout string, v any) string {
+	t, ok := toTime(v)
+	if !ok {
+		return ""
+	}
+	return t.Format(config.StrftimeToGoLayout(layout))
+}
+
+// toTime converts a time.Time or an RFC 3339 date string to a time
+func toTime(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, !t.IsZero()
+	case *time.Time:
+		return toTime(*t)
+	default:
+		parsed, err := time.Parse(time.RFC3339, toString(v))
+		return parsed, err == nil
+	}
+}
+
+// urlquery escapes a value for use in a URL query
+func urlquery(v any) string {
+	return url.QueryEscape(toString(v))
+}
+
+// lower converts a value to lower case
+func lower(v any) string {
+	return strings.ToLower(toString(v))
+}
+
+// slugify converts a value to a lower case URL slug: letters and digits are
+// kept, runs of anything else become a single dash
+func slugify(v any) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(striptags(v)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// first returns the first n elements of a slice, or all of them if there are
+// fewer
+func first(n int, list any) (any, error) {
+	items := reflect.ValueOf(list)
+	switch items.Kind() {
+	case reflect.Slice:
+	case reflect.Invalid:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("first: cannot take elements of %s", items.Type())
+	}
+	if n < 0 {
+		n = 0
+	}
+	if n > items.Len() {
+		n = items.Len()
+	}
+	return items.Slice(0, n).Interface(), nil
+}
+
+// groupBy groups the elements of a slice by the value of a struct field or
+// map key, e.g. {{range groupBy "ChannelName" .Items}}. Groups are in order
+// of their first element, and keep the order of the elements.
+func groupBy(field string, list any) ([]Group, error) {
+	items := reflect.ValueOf(list)
+	switch items.Kind() {
+	case reflect.Slice, reflect.Array:
+	case reflect.Invalid:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("groupBy: cannot group %s", items.Type())
+	}
+
+	var groups []Group
+	index := make(map[string]int)
+	for i := 0; i < items.Len(); i++ {
+		item := items.Index(i)
+		value, err := fieldValue(item, field)
+		if err != nil {
+			return nil, fmt.Errorf("groupBy: %w", err)
+		}
+
+		key := toString(value)
+		n, ok := index[key]
+		if !ok {
+			n = len(groups)
+			index[key] = n
+			groups = append(groups, Group{Key: key})
+		}
+		groups[n].Items = append(groups[n].Items, item.Interface())
+	}
+	return groups, nil
+}
+
+// fieldValue returns a struct field or map value of an element
+func fieldValue(item reflect.Value, field string) (any, error) {
+	for item.Kind() == reflect.Pointer || item.Kind() == reflect.Interface {
+		if item.IsNil() {
+			return nil, nil
+		}
+		item = item.Elem()
+	}
+
+	switch item.Kind() {
+	case reflect.Struct:
+		value := item.FieldByName(field)
+		if !value.IsValid() || !value.CanInterface() {
+			return nil, fmt.Errorf("%s has no field %s", item.Type(), field)
+		}
+		return value.Interface(), nil
+	case reflect.Map:
+		if item.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("cannot look up %s in %s", field, item.Type())
+		}
+		value := item.MapIndex(reflect.ValueOf(field).Convert(item.Type().Key()))
+		if !value.IsValid() {
+			return nil, nil
+		}
+		return value.Interface(), nil
+	default:
+		return nil, fmt.Errorf("cannot look up %s in %s", field, item.Type())
+	}
+}
+
+// toJSON encodes a value as JSON. In HTML templates the result can be used
+// in a <script> element as is.
+func toJSON(v any) (htmltemplate.JS, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("json: %w", err)
+	}
+	return htmltemplate.JS(data), nil
+}
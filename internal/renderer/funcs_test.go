@@ -0,0 +1,228 @@
+package renderer
+
+import (
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+func TestStriptags(t *testing.T) {
+	got := striptags(htmltemplate.HTML(`<p>Fish &amp; <b>chips</b></p>`))
+	if got != "Fish & chips" {
+		t.Errorf("striptags() = %q", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		n    int
+		in   string
+		want string
+	}{
+		{20, "Short title", "Short title"},
+		{11, "Short title", "Short title"},
+		{10, "Short title", "Short…"},
+		{5, "Supercalifragilistic", "Supe…"},
+		{4, "Über alles", "Übe…"},
+		{0, "No limit", "No limit"},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.n, tt.in); got != tt.want {
+			t.Errorf("truncate(%d, %q) = %q, want %q", tt.n, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExcerptFunc(t *testing.T) {
+	got := excerptFunc(2, htmltemplate.HTML("<p>One <em>two three</em></p>"))
+	if want := htmltemplate.HTML("<p>One <em>two…</em></p>"); got != want {
+		t.Errorf("excerpt() = %q, want %q", got, want)
+	}
+
+	// Plain strings such as .Author or .ChannelSubtitle are not markup
+	got = excerptFunc(5, `<img src=x onerror=alert(1)> hi`)
+	if want := htmltemplate.HTML(`&lt;img src=x onerror=alert(1)&gt; hi`); got != want {
+		t.Errorf("excerpt() of a string = %q, want %q", got, want)
+	}
+}
+
+func TestTimeago(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		in   any
+		want string
+	}{
+		{now.Add(-10 * time.Second), "just now"},
+		{now.Add(-time.Minute - time.Second), "1 minute ago"},
+		{now.Add(-3*time.Hour - time.Minute), "3 hours ago"},
+		{now.Add(-49 * time.Hour).Format(time.RFC3339), "2 days ago"},
+		{now.AddDate(0, 0, -95), "3 months ago"},
+		{now.AddDate(-2, 0, -1), "2 years ago"},
+		{now.Add(2*time.Hour + time.Minute), "2 hours from now"},
+		{time.Time{}, ""},
+		{"yesterday", ""},
+	}
+	for _, tt := range tests {
+		if got := timeago(tt.in); got != tt.want {
+			t.Errorf("timeago(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2025, 3, 7, 14, 5, 0, 0, time.UTC)
+	tests := []struct {
+		layout string
+		in     any
+		want   string
+	}{
+		{"%d %B %Y", date, "07 March 2025"},
+		{"2 Jan 2006 15:04", date, "7 Mar 2025 14:05"},
+		{"%Y-%m-%d", "2025-03-07T14:05:00Z", "2025-03-07"},
+		{"%Y", "", ""},
+	}
+	for _, tt := range tests {
+		if got := formatDate(tt.layout, tt.in); got != tt.want {
+			t.Errorf("formatDate(%q, %v) = %q, want %q", tt.layout, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestURLQuery(t *testing.T) {
+	if got := urlquery("Go & Rust?"); got != "Go+%26+Rust%3F" {
+		t.Errorf("urlquery() = %q", got)
+	}
+}
+
+func TestLower(t *testing.T) {
+	if got := lower("Planet GO"); got != "planet go" {
+		t.Errorf("lower() = %q", got)
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Hello, World!":           "hello-world",
+		"  Go 1.25 -- released  ": "go-1-25-released",
+		"<b>Über</b> Café":        "über-café",
+		"!!!":                     "",
+	}
+	for in, want := range tests {
+		if got := slugify(in); got != want {
+			t.Errorf("slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFirst(t *testing.T) {
+	items := []TemplateEntry{{Title: "a"}, {Title: "b"}, {Title: "c"}}
+
+	got, err := first(2, items)
+	if err != nil {
+		t.Fatalf("first() error = %v", err)
+	}
+	if want := items[:2]; !reflect.DeepEqual(got, want) {
+		t.Errorf("first(2) = %v, want %v", got, want)
+	}
+	if got, _ := first(10, items); len(got.([]TemplateEntry)) != 3 {
+		t.Errorf("first(10) = %v", got)
+	}
+	if _, err := first(1, "abc"); err == nil {
+		t.Error("first() of a string succeeded")
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	items := []TemplateEntry{
+		{Title: "a", ChannelName: "Blog"},
+		{Title: "b", ChannelName: "News"},
+		{Title: "c", ChannelName: "Blog"},
+	}
+
+	groups, err := groupBy("ChannelName", items)
+	if err != nil {
+		t.Fatalf("groupBy() error = %v", err)
+	}
+	want := []Group{
+		{Key: "Blog", Items: []any{items[0], items[2]}},
+		{Key: "News", Items: []any{items[1]}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("groupBy() = %+v, want %+v", groups, want)
+	}
+
+	maps := []map[string]any{{"tag": "go"}, {"tag": "rust"}, {"tag": "go"}}
+	if groups, _ := groupBy("tag", maps); len(groups) != 2 || len(groups[0].Items) != 2 {
+		t.Errorf("groupBy() of maps = %+v", groups)
+	}
+
+	if _, err := groupBy("Missing", items); err == nil {
+		t.Error("groupBy() with an unknown field succeeded")
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	got, err := toJSON(map[string]any{"title": "A & B", "count": 2})
+	if err != nil {
+		t.Fatalf("json() error = %v", err)
+	}
+	if want := htmltemplate.JS(`{"count":2,"title":"A \u0026 B"}`); got != want {
+		t.Errorf("json() = %s, want %s", got, want)
+	}
+	if _, err := toJSON(func() {}); err == nil {
+		t.Error("json() of a function succeeded")
+	}
+}
+
+func TestRenderer_TemplateFuncs(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputDir := filepath.Join(tmpDir, "output")
+
+	templates := map[string]string{
+		"index.html.tmpl": `{{range groupBy "ChannelName" .Items}}<h2 id="{{slugify .Key}}">{{lower .Key}}</h2>` +
+			`{{range .Items}}<a href="/search?q={{.Title | urlquery}}">{{.Title | truncate 12}}</a>{{end}}{{end}}` +
+			`{{range first 1 .Items}}{{excerpt 1 .Content}}|{{excerpt 3 .Author}}|{{striptags .Content}}|{{formatDate "%Y" .DateISO}}{{end}}` +
+			`<script>var n = {{json (len .Items)}};</script>`,
+		"atom.xml.tmpl": `{{range first 1 .Items}}<content type="html">{{excerpt 1 .Content}}</content>{{end}}`,
+	}
+
+	cfg := &config.Config{Planet: config.PlanetConfig{ItemsPerPage: 10, DateFormat: "2006-01-02"}}
+	date := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+	entries := []cache.Entry{
+		{Title: "Fish & Chips Friday", ChannelName: "Food Blog", Date: date, Content: "<p>Fish and <b>chips</b></p>", Author: "<i onmouseover=x>Bob</i>"},
+		{Title: "Pasta", ChannelName: "Food Blog", Date: date.Add(-time.Hour), Content: "Pasta"},
+	}
+
+	want := map[string]string{
+		"index.html": `<h2 id="food-blog">food blog</h2>` +
+			`<a href="/search?q=Fish&#43;%26&#43;Chips&#43;Friday">Fish &amp;…</a><a href="/search?q=Pasta">Pasta</a>` +
+			`<p>Fish…</p>|&lt;i onmouseover=x&gt;Bob&lt;/i&gt;|Fish and chips|2025` +
+			`<script>var n = 2;</script>`,
+		"atom.xml": `<content type="html">&lt;p&gt;Fish…&lt;/p&gt;</content>`,
+	}
+
+	for name, content := range templates {
+		tmplPath := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(tmplPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := New(outputDir).Render(tmplPath, entries, cfg); err != nil {
+			t.Fatalf("Render(%s) error = %v", name, err)
+		}
+
+		output := name[:len(name)-len(".tmpl")]
+		got, err := os.ReadFile(filepath.Join(outputDir, output))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want[output] {
+			t.Errorf("%s =\n%s\nwant\n%s", output, got, want[output])
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package renderer
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// jsonFeedVersion identifies JSON Feed 1.1
+const jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+// JSON Feed 1.1 document structure. Keys starting with an underscore are
+// extensions, which readers that don't know them ignore.
+
+type jsonFeed struct {
+	Version     string       `json:"version"`
+	Title       string       `json:"title"`
+	HomePageURL string       `json:"home_page_url,omitempty"`
+	FeedURL     string       `json:"feed_url"`
+	Authors     []jsonAuthor `json:"authors,omitempty"`
+	Items       []jsonItem   `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+type jsonItem struct {
+	ID            string       `json:"id"`
+	URL           string       `json:"url,omitempty"`
+	Title         string       `json:"title,omitempty"`
+	ContentHTML   string       `json:"content_html"`
+	DatePublished string       `json:"date_published,omitempty"`
+	Authors       []jsonAuthor `json:"authors,omitempty"`
+	Language      string       `json:"language,omitempty"`
+	Source        *jsonSource  `json:"_source,omitempty"`
+}
+
+// jsonSource describes the feed an item was aggregated from
+type jsonSource struct {
+	Title       string `json:"title,omitempty"`
+	HomePageURL string `json:"home_page_url,omitempty"`
+	FeedURL     string `json:"feed_url"`
+}
+
+// writeJSONFeed writes a JSON Feed 1.1 document of the entries
+func writeJSONFeed(w io.Writer, data TemplateData) error {
+	feed := jsonFeed{
+		Version:     jsonFeedVersion,
+		Title:       data.Name,
+		HomePageURL: data.Link,
+		FeedURL:     feedURL(data, jsonFile),
+		Items:       make([]jsonItem, 0, len(data.Items)),
+	}
+	if data.OwnerName != "" {
+		feed.Authors = []jsonAuthor{{Name: data.OwnerName}}
+	}
+
+	for _, item := range data.Items {
+		author := entryAuthor(item)
+		entry := jsonItem{
+			ID:            item.ID,
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentHTML:   string(item.FullContent),
+			DatePublished: item.DateISO,
+			Authors:       []jsonAuthor{{Name: author.Name, URL: author.URI}},
+			Language:      item.ContentLanguage,
+		}
+		if item.ChannelURL != "" {
+			entry.Source = &jsonSource{Title: channelTitle(item), HomePageURL: item.ChannelLink, FeedURL: item.ChannelURL}
+		}
+		feed.Items = append(feed.Items, entry)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(feed)
+}
@@ -0,0 +1,65 @@
+package renderer
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// OPML 2.0 document structure
+
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    opmlHead `xml:"head"`
+	Body    opmlBody `xml:"body"`
+}
+
+type opmlHead struct {
+	Title        string `xml:"title"`
+	DateModified string `xml:"dateModified,omitempty"`
+	OwnerName    string `xml:"ownerName,omitempty"`
+	OwnerEmail   string `xml:"ownerEmail,omitempty"`
+	Docs         string `xml:"docs"`
+}
+
+type opmlBody struct {
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+type opmlOutline struct {
+	Type    string `xml:"type,attr"`
+	Text    string `xml:"text,attr"`
+	Title   string `xml:"title,attr,omitempty"`
+	XMLURL  string `xml:"xmlUrl,attr"`
+	HTMLURL string `xml:"htmlUrl,attr,omitempty"`
+}
+
+// writeOPML writes an OPML 2.0 subscription list of all configured feeds
+func writeOPML(w io.Writer, data TemplateData) error {
+	doc := opmlDocument{
+		Version: "2.0",
+		Head: opmlHead{
+			Title:        data.Name,
+			DateModified: rfc822(data.DateISO),
+			OwnerName:    data.OwnerName,
+			OwnerEmail:   data.OwnerEmail,
+			Docs:         "http://opml.org/spec2.opml",
+		},
+	}
+
+	for _, channel := range data.Channels {
+		title := channel.Title
+		if title == "" {
+			title = channel.Name
+		}
+		doc.Body.Outlines = append(doc.Body.Outlines, opmlOutline{
+			Type:    "rss",
+			Text:    channel.Name,
+			Title:   title,
+			XMLURL:  channel.URL,
+			HTMLURL: channel.Link,
+		})
+	}
+
+	return writeXML(w, doc)
+}
@@ -0,0 +1,137 @@
+package renderer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+// Names of the built-in outputs
+const (
+	OutputAtom = "atom" // Atom 1.0, atom.xml
+	OutputRSS  = "rss"  // RSS 2.0, rss20.xml
+	OutputJSON = "json" // JSON Feed 1.1, feed.json
+	OutputOPML = "opml" // OPML 2.0 subscription list, opml.xml
+)
+
+// Files written by the built-in outputs
+const (
+	atomFile = "atom.xml"
+	rssFile  = "rss20.xml"
+	jsonFile = "feed.json"
+	opmlFile = "opml.xml"
+)
+
+// generatorURI identifies Planet Go in generated feeds
+const generatorURI = "https://github.com/alexey-ott/planet-go"
+
+// output is a built-in feed generator
+type output struct {
+	file  string // Output file name
+	write func(w io.Writer, data TemplateData) error
+}
+
+// outputs are the generators that can be enabled in the config
+var outputs = map[string]output{
+	OutputAtom: {file: atomFile, write: writeAtom},
+	OutputRSS:  {file: rssFile, write: writeRSS},
+	OutputJSON: {file: jsonFile, write: writeJSONFeed},
+	OutputOPML: {file: opmlFile, write: writeOPML},
+}
+
+// OutputNames returns the names of the built-in outputs, sorted
+func OutputNames() []string {
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckOutputs returns an error if a built-in output would write the same
+// file as one of the templates, e.g. outputs = atom with atom.xml.tmpl
+func CheckOutputs(templateFiles, names []string) error {
+	rendered := make(map[string]string, len(templateFiles))
+	for _, templatePath := range templateFiles {
+		rendered[templateOutputName(templatePath)] = templatePath
+	}
+	for _, name := range names {
+		out, ok := outputs[strings.ToLower(name)]
+		if !ok {
+			continue // Reported by RenderOutput
+		}
+		if templatePath, ok := rendered[out.file]; ok {
+			return fmt.Errorf("output %s would overwrite %s, rendered from template %s", name, out.file, templatePath)
+		}
+	}
+	return nil
+}
+
+// RenderOutput writes a built-in feed output. Like feed templates, it holds
+// the first page of entries (items_per_page and days_per_page).
+func (r *Renderer) RenderOutput(name string, entries []cache.Entry, cfg *config.Config) error {
+	out, ok := outputs[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("unknown output %q (available: %s)", name, strings.Join(OutputNames(), ", "))
+	}
+
+	pages := splitPages(sortByDate(entries), cfg.Planet.ItemsPerPage, cfg.Planet.DaysPerPage)
+	excerpts := excerptOptions{words: cfg.Planet.ExcerptWords, chars: cfg.Planet.ExcerptChars}
+	data := r.prepareTemplateData(pages[0], cfg, cfg.Planet.ActivityThreshold, excerpts)
+	data.PageNumber = 1
+	data.TotalPages = 1
+
+	return r.writeFile(out.file, func(w io.Writer) error {
+		if err := out.write(w, data); err != nil {
+			return fmt.Errorf("write %s output: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// feedURL returns the absolute URL of an output file on the planet
+func feedURL(data TemplateData, file string) string {
+	if data.Link == "" {
+		return file
+	}
+	return strings.TrimSuffix(data.Link, "/") + "/" + file
+}
+
+// channelTitle returns the title of an entry's feed, or its configured name
+func channelTitle(item TemplateEntry) string {
+	if item.ChannelTitle != "" {
+		return item.ChannelTitle
+	}
+	return item.ChannelName
+}
+
+// writeXML writes an XML document with a declaration and indentation
+func writeXML(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// rfc822 converts an RFC 3339 date to the RFC 822 format used by RSS and
+// OPML, or returns "" for an empty or malformed date
+func rfc822(iso string) string {
+	t, err := time.Parse(time.RFC3339, iso)
+	if err != nil {
+		return ""
+	}
+	return t.Format(time.RFC1123Z)
+}
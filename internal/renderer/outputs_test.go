@@ -0,0 +1,237 @@
+package renderer
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+// renderOutput renders a built-in output of two entries and returns the file
+func renderOutput(t *testing.T, name, file string) []byte {
+	t.Helper()
+	outputDir := t.TempDir()
+
+	cfg := &config.Config{
+		Planet: config.PlanetConfig{
+			Name:           "Planet Test",
+			Link:           "https://planet.example.com/",
+			OwnerName:      "Owner",
+			OwnerEmail:     "owner@example.com",
+			ItemsPerPage:   10,
+			DateFormat:     "2006-01-02",
+			CacheDirectory: t.TempDir(),
+		},
+		Feeds: []config.FeedConfig{
+			{URL: "https://blog.example.com/atom.xml", Name: "Blog"},
+			{URL: "https://other.example.com/rss", Name: "Other"},
+		},
+	}
+	date := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := []cache.Entry{
+		{
+			Title:             "Fish & Chips",
+			Link:              "https://blog.example.com/fish",
+			ID:                "tag:blog.example.com,2025:fish",
+			Content:           `<p>Fish &amp; <b>chips</b></p>`,
+			Author:            "Alice",
+			AuthorEmail:       "alice@example.com",
+			Date:              date,
+			ChannelName:       "Blog",
+			ChannelTitle:      "Alice's Blog",
+			ChannelLink:       "https://blog.example.com/",
+			ChannelURL:        "https://blog.example.com/atom.xml",
+			ChannelID:         "urn:uuid:blog",
+			ChannelSubtitle:   "Cooking",
+			ChannelRights:     "CC BY",
+			ChannelUpdated:    date,
+			ChannelAuthorName: "Alice",
+			ContentLanguage:   "en",
+		},
+		{
+			Title:       "Undated",
+			Link:        "https://other.example.com/undated",
+			ID:          "https://other.example.com/undated",
+			Content:     "Plain",
+			ChannelName: "Other",
+			ChannelURL:  "https://other.example.com/rss",
+		},
+	}
+
+	if err := New(outputDir).RenderOutput(name, entries, cfg); err != nil {
+		t.Fatalf("RenderOutput(%q) error = %v", name, err)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+func TestRenderOutput_Atom(t *testing.T) {
+	content := renderOutput(t, OutputAtom, "atom.xml")
+
+	var feed atomFeed
+	if err := xml.Unmarshal(content, &feed); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, content)
+	}
+	if feed.ID != "https://planet.example.com/atom.xml" || feed.Updated == "" || feed.Author == nil {
+		t.Errorf("feed id/updated/author = %q/%q/%v", feed.ID, feed.Updated, feed.Author)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(feed.Entries))
+	}
+
+	entry := feed.Entries[0]
+	if entry.Title.Body != "Fish & Chips" || entry.Content.Type != "html" || entry.Content.Body != `<p>Fish &amp; <b>chips</b></p>` {
+		t.Errorf("entry title/content = %q/%+v", entry.Title.Body, entry.Content)
+	}
+	if entry.Updated != "2025-01-02T03:04:05Z" || entry.Author.Name != "Alice" {
+		t.Errorf("entry updated/author = %q/%q", entry.Updated, entry.Author.Name)
+	}
+	source := entry.Source
+	if source == nil || source.ID != "urn:uuid:blog" || source.Title.Body != "Alice's Blog" ||
+		source.Subtitle.Body != "Cooking" || source.Rights.Body != "CC BY" || source.Updated == "" {
+		t.Errorf("entry source = %+v", source)
+	}
+	if !strings.Contains(string(content), `<link rel="self" href="https://blog.example.com/atom.xml">`) {
+		t.Errorf("source self link missing:\n%s", content)
+	}
+
+	// Entries without a date or author still get the required elements
+	undated := feed.Entries[1]
+	if undated.Updated != feed.Updated || undated.Author.Name != "Other" || undated.Source.ID != "https://other.example.com/rss" {
+		t.Errorf("undated entry = %+v", undated)
+	}
+}
+
+func TestRenderOutput_RSS(t *testing.T) {
+	content := renderOutput(t, OutputRSS, "rss20.xml")
+
+	var feed struct {
+		Channel struct {
+			Title       string `xml:"title"`
+			Description string `xml:"description"`
+			Items       []struct {
+				Title       string `xml:"title"`
+				Description string `xml:"description"`
+				Author      string `xml:"author"`
+				Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+				GUID        struct {
+					IsPermaLink bool   `xml:"isPermaLink,attr"`
+					ID          string `xml:",chardata"`
+				} `xml:"guid"`
+				PubDate string `xml:"pubDate"`
+				Source  struct {
+					URL   string `xml:"url,attr"`
+					Title string `xml:",chardata"`
+				} `xml:"source"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(content, &feed); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, content)
+	}
+	if len(feed.Channel.Items) != 2 || feed.Channel.Description == "" {
+		t.Fatalf("channel = %+v", feed.Channel)
+	}
+
+	item := feed.Channel.Items[0]
+	if item.Description != `<p>Fish &amp; <b>chips</b></p>` || item.Author != "alice@example.com (Alice)" {
+		t.Errorf("item description/author = %q/%q", item.Description, item.Author)
+	}
+	if item.GUID.IsPermaLink || item.PubDate != "Thu, 02 Jan 2025 03:04:05 +0000" {
+		t.Errorf("item guid/pubDate = %+v/%q", item.GUID, item.PubDate)
+	}
+	if item.Source.URL != "https://blog.example.com/atom.xml" || item.Source.Title != "Alice's Blog" {
+		t.Errorf("item source = %+v", item.Source)
+	}
+
+	// Without an email the author is a dc:creator; a link ID is a permalink
+	other := feed.Channel.Items[1]
+	if other.Author != "" || other.Creator != "Other" || !other.GUID.IsPermaLink {
+		t.Errorf("second item = %+v", other)
+	}
+}
+
+func TestRenderOutput_JSONFeed(t *testing.T) {
+	content := renderOutput(t, OutputJSON, "feed.json")
+
+	var feed jsonFeed
+	if err := json.Unmarshal(content, &feed); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, content)
+	}
+	if feed.Version != "https://jsonfeed.org/version/1.1" || feed.FeedURL != "https://planet.example.com/feed.json" {
+		t.Errorf("feed version/url = %q/%q", feed.Version, feed.FeedURL)
+	}
+	if len(feed.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(feed.Items))
+	}
+
+	item := feed.Items[0]
+	if item.ContentHTML != `<p>Fish &amp; <b>chips</b></p>` || item.DatePublished != "2025-01-02T03:04:05Z" {
+		t.Errorf("item content/date = %q/%q", item.ContentHTML, item.DatePublished)
+	}
+	if item.Source == nil || item.Source.FeedURL != "https://blog.example.com/atom.xml" {
+		t.Errorf("item source = %+v", item.Source)
+	}
+	if strings.Contains(string(content), `\u003c`) {
+		t.Error("HTML in the content was escaped as \\u003c")
+	}
+}
+
+func TestRenderOutput_OPML(t *testing.T) {
+	content := renderOutput(t, OutputOPML, "opml.xml")
+
+	var doc opmlDocument
+	if err := xml.Unmarshal(content, &doc); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, content)
+	}
+	if doc.Version != "2.0" || doc.Head.DateModified == "" {
+		t.Errorf("version/dateModified = %q/%q", doc.Version, doc.Head.DateModified)
+	}
+	want := []opmlOutline{
+		{Type: "rss", Text: "Blog", Title: "Alice's Blog", XMLURL: "https://blog.example.com/atom.xml", HTMLURL: "https://blog.example.com/"},
+		{Type: "rss", Text: "Other", Title: "Other", XMLURL: "https://other.example.com/rss"},
+	}
+	if len(doc.Body.Outlines) != len(want) {
+		t.Fatalf("outlines = %+v", doc.Body.Outlines)
+	}
+	for i := range want {
+		if doc.Body.Outlines[i] != want[i] {
+			t.Errorf("outline[%d] = %+v, want %+v", i, doc.Body.Outlines[i], want[i])
+		}
+	}
+}
+
+func TestRenderOutput_Unknown(t *testing.T) {
+	cfg := &config.Config{}
+	if err := New(t.TempDir()).RenderOutput("rdf", nil, cfg); err == nil {
+		t.Error("RenderOutput() with an unknown output succeeded")
+	}
+}
+
+func TestOutputNames(t *testing.T) {
+	// The config validates outputs against its own list
+	if got := OutputNames(); !slices.Equal(got, config.OutputNames) {
+		t.Errorf("OutputNames() = %v, config.OutputNames = %v", got, config.OutputNames)
+	}
+}
+
+func TestCheckOutputs(t *testing.T) {
+	templates := []string{"/planet/index.html.tmpl", "/planet/atom.xml.tmpl"}
+
+	if err := CheckOutputs(templates, []string{OutputRSS, OutputJSON}); err != nil {
+		t.Errorf("CheckOutputs() error = %v, want outputs with their own files accepted", err)
+	}
+	if err := CheckOutputs(templates, []string{OutputRSS, OutputAtom}); err == nil {
+		t.Error("CheckOutputs() accepted an output overwriting atom.xml.tmpl")
+	}
+}
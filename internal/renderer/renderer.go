@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"html/template"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/alexey-ott/planet-go/internal/cache"
@@ -16,6 +18,10 @@ import (
 // Renderer handles template rendering
 type Renderer struct {
 	outputDir string
+
+	// channels caches channel links/titles from the whole cache, keyed by
+	// channel name. Loaded lazily once per renderer and shared by all pages.
+	channels map[string]Channel
 }
 
 // New creates a new renderer
@@ -34,6 +40,26 @@ type TemplateData struct {
 	DateISO    string
 	Items      []TemplateEntry
 	Channels   []Channel
+
+	// Pagination (HTML outputs are split into index.html, page/2.html, ...)
+	PageNumber int
+	TotalPages int
+	PrevPage   string // Relative URL of the previous (newer) page, empty on the first page
+	NextPage   string // Relative URL of the next (older) page, empty on the last page
+	Root       string // Relative prefix to the output root, e.g. "../" on page/2.html
+
+	// Date archives (archive/2025/01.html)
+	Archive  string        // Month label on archive pages, e.g. "January 2025"
+	Archives []ArchiveLink // All archive months, newest first
+}
+
+// ArchiveLink describes a monthly archive page
+type ArchiveLink struct {
+	Label string // e.g. "January 2025"
+	Year  int
+	Month int
+	Count int    // Number of entries in the month
+	URL   string // Relative URL from the current page
 }
 
 // TemplateEntry represents an entry for templates
@@ -64,6 +90,21 @@ type TemplateEntry struct {
 	ChannelID          string
 	ChannelUpdatedISO  string
 	ChannelRights      string
+
+	// Excerpt of the content, cut at excerpt_words/excerpt_chars. Templates
+	// with content = excerpt get it as Content too, and the full post as
+	// FullContent.
+	Excerpt     template.HTML
+	FullContent template.HTML
+	Truncated   bool // Excerpt is shorter than the full content
+	WordCount   int
+	ReadingTime int // Minutes, at 200 words per minute
+}
+
+// excerptOptions control the excerpts generated for a template
+type excerptOptions struct {
+	words, chars int
+	replace      bool // Show the excerpt as the entry content
 }
 
 // Channel represents a feed channel
@@ -72,9 +113,20 @@ type Channel struct {
 	Link  string // HTML page URL
 	Title string
 	URL   string // Feed URL
+
+	// Activity, computed from all cached entries of the feed
+	Inactive       bool   // No entries within activity_threshold days
+	LastUpdated    string // Date of the newest entry (date_format)
+	LastUpdatedISO string
+	EntryCount     int // Number of cached entries
+
+	lastUpdated time.Time
 }
 
-// Render renders a template with entries
+// Render renders a template with entries.
+// HTML outputs are written as a series of pages (index.html, page/2.html, ...)
+// plus monthly archives (archive/2025/01.html); other outputs such as feeds
+// only get the first page.
 func (r *Renderer) Render(templatePath string, entries []cache.Entry, cfg *config.Config) error {
 	// Ensure output directory exists
 	if err := os.MkdirAll(r.outputDir, 0755); err != nil {
@@ -84,24 +136,127 @@ func (r *Renderer) Render(templatePath string, entries []cache.Entry, cfg *confi
 	// Sort entries by date (newest first)
 	sorted := sortByDate(entries)
 
+	// Template-specific days_per_page overrides the global setting
+	daysPerPage := cfg.Planet.DaysPerPage
+	if tmplConfig, ok := cfg.Templates[templatePath]; ok && tmplConfig.DaysPerPage > 0 {
+		daysPerPage = tmplConfig.DaysPerPage
+	}
+
+	// Template-specific activity_threshold overrides the global setting
+	activityThreshold := cfg.Planet.ActivityThreshold
+	if tmplConfig, ok := cfg.Templates[templatePath]; ok && tmplConfig.ActivityThreshold >= 0 {
+		activityThreshold = tmplConfig.ActivityThreshold
+	}
+
+	// Template-specific excerpt settings override the global ones
+	excerpts := excerptOptions{words: cfg.Planet.ExcerptWords, chars: cfg.Planet.ExcerptChars}
+	if tmplConfig, ok := cfg.Templates[templatePath]; ok {
+		if tmplConfig.ExcerptWords >= 0 {
+			excerpts.words = tmplConfig.ExcerptWords
+		}
+		if tmplConfig.ExcerptChars >= 0 {
+			excerpts.chars = tmplConfig.ExcerptChars
+		}
+		excerpts.replace = tmplConfig.Content == "excerpt"
+	}
+
 	// Apply pagination
-	paginated := paginate(sorted, cfg.Planet.ItemsPerPage, cfg.Planet.DaysPerPage)
+	pages := splitPages(sorted, cfg.Planet.ItemsPerPage, daysPerPage)
 
-	// Prepare template data
-	data := r.prepareTemplateData(paginated, cfg)
+	outputName := templateOutputName(templatePath)
 
-	// Parse template
-	tmpl, err := template.ParseFiles(templatePath)
+	// Parse template with html/template for HTML outputs and an XML-escaping
+	// text/template for feeds, unless the template section sets an engine
+	tmpl, err := parseTemplate(templatePath, engineFor(outputName, cfg.Templates[templatePath].Engine))
 	if err != nil {
 		return fmt.Errorf("parse template: %w", err)
 	}
 
-	// Determine output filename (remove .tmpl extension)
-	outputName := filepath.Base(templatePath)
-	if ext := filepath.Ext(outputName); ext == ".tmpl" {
-		outputName = outputName[:len(outputName)-len(ext)]
+	if !isHTMLOutput(outputName) {
+		data := r.prepareTemplateData(pages[0], cfg, activityThreshold, excerpts)
+		data.PageNumber = 1
+		data.TotalPages = 1
+		return r.writePage(tmpl, outputName, data)
+	}
+
+	if cfg.Planet.MaxPages > 0 && len(pages) > cfg.Planet.MaxPages {
+		pages = pages[:cfg.Planet.MaxPages]
+	}
+
+	layout := newPageLayout(outputName)
+
+	var months []archiveMonth
+	if cfg.Planet.Archives {
+		months = groupByMonth(sorted)
+	}
+
+	// Remove pages left over from previous runs with more pages
+	if err := os.RemoveAll(filepath.Join(r.outputDir, layout.pageDir)); err != nil {
+		return fmt.Errorf("remove old pages: %w", err)
+	}
+
+	for i, page := range pages {
+		number := i + 1
+		path := layout.pagePath(number)
+
+		data := r.prepareTemplateData(page, cfg, activityThreshold, excerpts)
+		data.PageNumber = number
+		data.TotalPages = len(pages)
+		data.Root = rootPrefix(path)
+		if number > 1 {
+			data.PrevPage = relativeURL(path, layout.pagePath(number-1))
+		}
+		if number < len(pages) {
+			data.NextPage = relativeURL(path, layout.pagePath(number+1))
+		}
+		data.Archives = archiveLinks(path, layout, months)
+
+		if err := r.writePage(tmpl, path, data); err != nil {
+			return err
+		}
+	}
+
+	for i, month := range months {
+		path := layout.archivePath(month.year, month.month)
+
+		data := r.prepareTemplateData(month.entries, cfg, activityThreshold, excerpts)
+		data.PageNumber = 1
+		data.TotalPages = 1
+		data.Root = rootPrefix(path)
+		data.Archive = month.label()
+		if i > 0 {
+			data.PrevPage = relativeURL(path, layout.archivePath(months[i-1].year, months[i-1].month))
+		}
+		if i < len(months)-1 {
+			data.NextPage = relativeURL(path, layout.archivePath(months[i+1].year, months[i+1].month))
+		}
+		data.Archives = archiveLinks(path, layout, months)
+
+		if err := r.writePage(tmpl, path, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writePage executes the template into a file relative to the output directory
+func (r *Renderer) writePage(tmpl executor, relPath string, data TemplateData) error {
+	return r.writeFile(relPath, func(w io.Writer) error {
+		// Execute template
+		if err := tmpl.Execute(w, data); err != nil {
+			return fmt.Errorf("execute template %s: %w", relPath, err)
+		}
+		return nil
+	})
+}
+
+// writeFile creates a file below the output directory and passes it to write
+func (r *Renderer) writeFile(relPath string, write func(w io.Writer) error) error {
+	outputPath := filepath.Join(r.outputDir, filepath.FromSlash(relPath))
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
 	}
-	outputPath := filepath.Join(r.outputDir, outputName)
 
 	// Create output file
 	f, err := os.Create(outputPath)
@@ -110,12 +265,122 @@ func (r *Renderer) Render(templatePath string, entries []cache.Entry, cfg *confi
 	}
 	defer f.Close()
 
-	// Execute template
-	if err := tmpl.Execute(f, data); err != nil {
-		return fmt.Errorf("execute template: %w", err)
+	return write(f)
+}
+
+// isHTMLOutput reports whether an output file is an HTML page that gets paginated
+func isHTMLOutput(outputName string) bool {
+	ext := strings.ToLower(filepath.Ext(outputName))
+	return ext == ".html" || ext == ".htm"
+}
+
+// templateOutputName returns the file a template renders to: its name
+// without the .tmpl extension
+func templateOutputName(templatePath string) string {
+	outputName := filepath.Base(templatePath)
+	if ext := filepath.Ext(outputName); ext == ".tmpl" {
+		outputName = outputName[:len(outputName)-len(ext)]
+	}
+	return outputName
+}
+
+// pageLayout maps page numbers and archive months to output paths.
+// For index.html pages go to page/N.html and archives to archive/YYYY/MM.html;
+// other HTML outputs (e.g. all.html) use all/page/N.html and all/archive/...
+type pageLayout struct {
+	outputName string
+	pageDir    string
+	archiveDir string
+	ext        string
+}
+
+func newPageLayout(outputName string) pageLayout {
+	ext := filepath.Ext(outputName)
+	stem := strings.TrimSuffix(outputName, ext)
+
+	layout := pageLayout{
+		outputName: outputName,
+		pageDir:    "page",
+		archiveDir: "archive",
+		ext:        ext,
+	}
+	if stem != "index" {
+		layout.pageDir = stem + "/page"
+		layout.archiveDir = stem + "/archive"
 	}
+	return layout
+}
 
-	return nil
+// pagePath returns the output path of page number (1-based)
+func (l pageLayout) pagePath(number int) string {
+	if number <= 1 {
+		return l.outputName
+	}
+	return fmt.Sprintf("%s/%d%s", l.pageDir, number, l.ext)
+}
+
+// archivePath returns the output path of a monthly archive page
+func (l pageLayout) archivePath(year, month int) string {
+	return fmt.Sprintf("%s/%04d/%02d%s", l.archiveDir, year, month, l.ext)
+}
+
+// rootPrefix returns the relative prefix from a page back to the output root
+func rootPrefix(path string) string {
+	return strings.Repeat("../", strings.Count(path, "/"))
+}
+
+// relativeURL returns the URL of target relative to the page at from
+// (both are slash-separated paths relative to the output root)
+func relativeURL(from, target string) string {
+	return rootPrefix(from) + target
+}
+
+// archiveMonth groups entries published in one calendar month
+type archiveMonth struct {
+	year    int
+	month   int
+	entries []cache.Entry
+}
+
+func (m archiveMonth) label() string {
+	return time.Date(m.year, time.Month(m.month), 1, 0, 0, 0, 0, time.UTC).Format("January 2006")
+}
+
+// groupByMonth groups date-sorted entries by month, newest first.
+// Entries without a date are not archived.
+func groupByMonth(sorted []cache.Entry) []archiveMonth {
+	var months []archiveMonth
+	for _, entry := range sorted {
+		if entry.Date.IsZero() {
+			continue
+		}
+		year, month := entry.Date.Year(), int(entry.Date.Month())
+		last := len(months) - 1
+		if last < 0 || months[last].year != year || months[last].month != month {
+			months = append(months, archiveMonth{year: year, month: month})
+			last++
+		}
+		months[last].entries = append(months[last].entries, entry)
+	}
+	return months
+}
+
+// archiveLinks builds the archive navigation as seen from the page at from
+func archiveLinks(from string, layout pageLayout, months []archiveMonth) []ArchiveLink {
+	if len(months) == 0 {
+		return nil
+	}
+	links := make([]ArchiveLink, 0, len(months))
+	for _, month := range months {
+		links = append(links, ArchiveLink{
+			Label: month.label(),
+			Year:  month.year,
+			Month: month.month,
+			Count: len(month.entries),
+			URL:   relativeURL(from, layout.archivePath(month.year, month.month)),
+		})
+	}
+	return links
 }
 
 // sortByDate sorts entries by date (newest first)
@@ -130,34 +395,51 @@ func sortByDate(entries []cache.Entry) []cache.Entry {
 	return sorted
 }
 
-// paginate limits entries by count and days
+// paginate returns the first page of entries
 func paginate(entries []cache.Entry, itemsPerPage, daysPerPage int) []cache.Entry {
-	if len(entries) == 0 {
-		return entries
-	}
+	return splitPages(entries, itemsPerPage, daysPerPage)[0]
+}
 
-	// Apply items per page limit
-	if itemsPerPage > 0 && len(entries) > itemsPerPage {
-		entries = entries[:itemsPerPage]
+// splitPages splits date-sorted entries into pages holding at most
+// itemsPerPage entries and at most daysPerPage distinct days of posts.
+// Zero limits are ignored. There is always at least one (possibly empty) page.
+func splitPages(entries []cache.Entry, itemsPerPage, daysPerPage int) [][]cache.Entry {
+	if len(entries) == 0 || (itemsPerPage <= 0 && daysPerPage <= 0) {
+		return [][]cache.Entry{entries}
 	}
 
-	// Apply days per page limit
-	if daysPerPage > 0 {
-		cutoff := time.Now().AddDate(0, 0, -daysPerPage)
-		filtered := make([]cache.Entry, 0, len(entries))
-		for _, entry := range entries {
-			if entry.Date.After(cutoff) {
-				filtered = append(filtered, entry)
-			}
+	var pages [][]cache.Entry
+	var current []cache.Entry
+	var lastDay string
+	days := 0
+
+	for _, entry := range entries {
+		day := entry.Date.Format("2006-01-02")
+		newDay := len(current) == 0 || day != lastDay
+
+		pageFull := itemsPerPage > 0 && len(current) >= itemsPerPage
+		tooManyDays := daysPerPage > 0 && newDay && days >= daysPerPage
+		if len(current) > 0 && (pageFull || tooManyDays) {
+			pages = append(pages, current)
+			current = nil
+			days = 0
+			newDay = true
+		}
+
+		if newDay {
+			days++
 		}
-		entries = filtered
+		current = append(current, entry)
+		lastDay = day
 	}
 
-	return entries
+	return append(pages, current)
 }
 
-// prepareTemplateData converts entries to template data
-func (r *Renderer) prepareTemplateData(entries []cache.Entry, cfg *config.Config) TemplateData {
+// prepareTemplateData converts entries to template data. Feeds without entries
+// in the last activityThreshold days (unless overridden per feed) are marked
+// inactive; 0 disables the check.
+func (r *Renderer) prepareTemplateData(entries []cache.Entry, cfg *config.Config, activityThreshold int, excerpts excerptOptions) TemplateData {
 	data := TemplateData{
 		Name:       cfg.Planet.Name,
 		Link:       cfg.Planet.Link,
@@ -184,17 +466,33 @@ func (r *Renderer) prepareTemplateData(entries []cache.Entry, cfg *config.Config
 
 	// Load channel links from ALL cache entries (not just filtered ones being rendered)
 	// This ensures channels have proper homepage links even if no recent entries
-	cacheInstance := cache.New(cfg.Planet.CacheDirectory)
-	if allEntries, err := cacheInstance.LoadAll(); err == nil {
-		for _, entry := range allEntries {
-			if ch, exists := channelMap[entry.ChannelName]; exists && ch.Link == "" {
-				ch.Link = entry.ChannelLink
-				ch.Title = entry.ChannelTitle
-				channelMap[entry.ChannelName] = ch
+	for name, cached := range r.cachedChannels(cfg) {
+		if ch, exists := channelMap[name]; exists {
+			ch.Link = cached.Link
+			ch.Title = cached.Title
+			ch.EntryCount = cached.EntryCount
+			ch.lastUpdated = cached.lastUpdated
+			if !cached.lastUpdated.IsZero() {
+				ch.LastUpdated = cached.lastUpdated.Format(cfg.Planet.DateFormat)
+				ch.LastUpdatedISO = cached.lastUpdated.Format(time.RFC3339)
 			}
+			channelMap[name] = ch
 		}
 	}
 
+	// Mark feeds without recent entries as inactive. Feeds without any cached
+	// entries, e.g. new subscriptions, are not marked.
+	now := time.Now()
+	for _, feed := range cfg.Feeds {
+		threshold := feed.ActivityThreshold(activityThreshold)
+		if threshold <= 0 {
+			continue
+		}
+		ch := channelMap[feed.Name]
+		ch.Inactive = !ch.lastUpdated.IsZero() && ch.lastUpdated.Before(now.AddDate(0, 0, -threshold))
+		channelMap[feed.Name] = ch
+	}
+
 	// Track previous entry for NewDate/NewChannel flags
 	var prevDate string
 	var prevChannel string
@@ -219,6 +517,9 @@ func (r *Renderer) prepareTemplateData(entries []cache.Entry, cfg *config.Config
 			channelUpdatedISO = entry.ChannelUpdated.Format(time.RFC3339)
 		}
 
+		summary, truncated := excerpt(entry.Content, excerpts.words, excerpts.chars)
+		words := wordCount(entry.Content)
+
 		item := TemplateEntry{
 			Title:        entry.Title,
 			Link:         entry.Link,
@@ -246,6 +547,15 @@ func (r *Renderer) prepareTemplateData(entries []cache.Entry, cfg *config.Config
 			ChannelID:          entry.ChannelID,
 			ChannelUpdatedISO:  channelUpdatedISO,
 			ChannelRights:      entry.ChannelRights,
+
+			Excerpt:     template.HTML(summary),
+			FullContent: template.HTML(entry.Content),
+			Truncated:   truncated,
+			WordCount:   words,
+			ReadingTime: readingTime(words),
+		}
+		if excerpts.replace {
+			item.Content = item.Excerpt
 		}
 
 		data.Items = append(data.Items, item)
@@ -275,6 +585,51 @@ func (r *Renderer) prepareTemplateData(entries []cache.Entry, cfg *config.Config
 	return data
 }
 
+// cachedChannels returns channel links, titles and activity found in the cache,
+// keyed by channel name. The cache is read once and reused for every rendered page.
+func (r *Renderer) cachedChannels(cfg *config.Config) map[string]Channel {
+	if r.channels != nil {
+		return r.channels
+	}
+
+	r.channels = make(map[string]Channel)
+	cacheInstance, err := cache.Open(cfg.Planet.CacheDirectory, cfg.Planet.CacheBackend)
+	if err != nil {
+		slog.Warn("failed to open cache, channels lack links and activity", "error", err)
+		return r.channels
+	}
+	defer cacheInstance.Close()
+
+	allEntries, err := cacheInstance.LoadAll(cfg.FeedURLs())
+	if err != nil {
+		slog.Warn("failed to load cached entries, channels lack links and activity", "error", err)
+	} else {
+		for _, entry := range allEntries {
+			channel, seen := r.channels[entry.ChannelName]
+			if !seen {
+				channel = Channel{
+					Link:  entry.ChannelLink,
+					Title: entry.ChannelTitle,
+				}
+			}
+			channel.EntryCount++
+
+			// Entries without a date count from when they were first seen
+			updated := entry.Date
+			if updated.IsZero() {
+				updated = entry.FirstSeen
+			}
+			if updated.After(channel.lastUpdated) {
+				channel.lastUpdated = updated
+			}
+
+			r.channels[entry.ChannelName] = channel
+		}
+	}
+
+	return r.channels
+}
+
 // CopyStaticFiles copies static assets from source to output directory
 // This mirrors the Python version's behavior where static files live alongside output
 func (r *Renderer) CopyStaticFiles(staticSourceDir string) error {
@@ -3,6 +3,7 @@ package renderer
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -60,6 +61,140 @@ func TestPaginate_DaysPerPage(t *testing.T) {
 	}
 }
 
+func TestSplitPages(t *testing.T) {
+	day := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	entries := []cache.Entry{
+		{Title: "A", Date: day},
+		{Title: "B", Date: day.Add(-1 * time.Hour)},
+		{Title: "C", Date: day.AddDate(0, 0, -1)},
+		{Title: "D", Date: day.AddDate(0, 0, -2)},
+		{Title: "E", Date: day.AddDate(0, 0, -3)},
+	}
+
+	tests := []struct {
+		name         string
+		itemsPerPage int
+		daysPerPage  int
+		want         []int // page sizes
+	}{
+		{name: "no limits", want: []int{5}},
+		{name: "items per page", itemsPerPage: 2, want: []int{2, 2, 1}},
+		{name: "days per page", daysPerPage: 2, want: []int{3, 2}},
+		{name: "both limits", itemsPerPage: 2, daysPerPage: 2, want: []int{2, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := splitPages(entries, tt.itemsPerPage, tt.daysPerPage)
+			if len(pages) != len(tt.want) {
+				t.Fatalf("len(pages) = %d, want %d", len(pages), len(tt.want))
+			}
+			for i, size := range tt.want {
+				if len(pages[i]) != size {
+					t.Errorf("len(pages[%d]) = %d, want %d", i, len(pages[i]), size)
+				}
+			}
+		})
+	}
+}
+
+func TestPageLayout(t *testing.T) {
+	index := newPageLayout("index.html")
+	if got := index.pagePath(1); got != "index.html" {
+		t.Errorf("pagePath(1) = %q, want index.html", got)
+	}
+	if got := index.pagePath(2); got != "page/2.html" {
+		t.Errorf("pagePath(2) = %q, want page/2.html", got)
+	}
+	if got := index.archivePath(2025, 1); got != "archive/2025/01.html" {
+		t.Errorf("archivePath() = %q, want archive/2025/01.html", got)
+	}
+
+	other := newPageLayout("all.html")
+	if got := other.pagePath(3); got != "all/page/3.html" {
+		t.Errorf("pagePath(3) = %q, want all/page/3.html", got)
+	}
+
+	if got := relativeURL("page/2.html", "index.html"); got != "../index.html" {
+		t.Errorf("relativeURL() = %q, want ../index.html", got)
+	}
+	if got := rootPrefix("archive/2025/01.html"); got != "../../" {
+		t.Errorf("rootPrefix() = %q, want ../../", got)
+	}
+}
+
+func TestRenderer_RenderPages(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputDir := filepath.Join(tmpDir, "output")
+	tmplPath := filepath.Join(tmpDir, "index.html.tmpl")
+
+	tmplContent := `page {{.PageNumber}}/{{.TotalPages}}{{if .Archive}} archive {{.Archive}}{{end}}
+prev={{.PrevPage}} next={{.NextPage}}
+{{range .Items}}<div>{{.Title}}</div>
+{{end}}{{range .Archives}}<a href="{{.URL}}">{{.Label}} ({{.Count}})</a>
+{{end}}`
+
+	if err := os.WriteFile(tmplPath, []byte(tmplContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		Planet: config.PlanetConfig{
+			Name:           "Test Planet",
+			CacheDirectory: filepath.Join(tmpDir, "cache"),
+			ItemsPerPage:   2,
+			DateFormat:     "2006-01-02",
+			Archives:       true,
+		},
+	}
+
+	entries := []cache.Entry{
+		{Title: "Feb 2", Date: time.Date(2025, 2, 2, 0, 0, 0, 0, time.UTC)},
+		{Title: "Feb 1", Date: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{Title: "Jan 31", Date: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)},
+	}
+
+	if err := New(outputDir).Render(tmplPath, entries, cfg); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	readOutput := func(name string) string {
+		t.Helper()
+		content, err := os.ReadFile(filepath.Join(outputDir, name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		return string(content)
+	}
+
+	first := readOutput("index.html")
+	if !strings.Contains(first, "page 1/2") || !strings.Contains(first, "next=page/2.html") {
+		t.Errorf("index.html has wrong pagination:\n%s", first)
+	}
+	if strings.Contains(first, "Jan 31") {
+		t.Error("index.html should not contain entries from page 2")
+	}
+	if !strings.Contains(first, `href="archive/2025/01.html">January 2025 (1)`) {
+		t.Errorf("index.html is missing archive links:\n%s", first)
+	}
+
+	second := readOutput("page/2.html")
+	if !strings.Contains(second, "page 2/2") || !strings.Contains(second, "prev=../index.html") {
+		t.Errorf("page/2.html has wrong pagination:\n%s", second)
+	}
+	if !strings.Contains(second, "Jan 31") {
+		t.Error("page/2.html does not contain its entry")
+	}
+
+	archive := readOutput("archive/2025/02.html")
+	if !strings.Contains(archive, "archive February 2025") || !strings.Contains(archive, "Feb 1") {
+		t.Errorf("archive page has wrong content:\n%s", archive)
+	}
+	if !strings.Contains(archive, "next=../../archive/2025/01.html") {
+		t.Errorf("archive page has wrong navigation:\n%s", archive)
+	}
+}
+
 func TestRenderer_Render(t *testing.T) {
 	tmpDir := t.TempDir()
 	outputDir := filepath.Join(tmpDir, "output")
@@ -142,3 +277,120 @@ func containsSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestRenderer_ActivityThreshold(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputDir := filepath.Join(tmpDir, "output")
+	cacheDir := filepath.Join(tmpDir, "cache")
+	tmplPath := filepath.Join(tmpDir, "opml.xml.tmpl")
+
+	tmplContent := `{{range .Channels}}{{.Name}} inactive={{.Inactive}} count={{.EntryCount}} updated={{.LastUpdated}}
+{{end}}`
+	if err := os.WriteFile(tmplPath, []byte(tmplContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	c := cache.New(cacheDir)
+	c.SaveEntries("https://active.example.com/feed", []cache.Entry{
+		{ID: "1", ChannelName: "Active", Date: now.AddDate(0, 0, -1)},
+		{ID: "2", ChannelName: "Active", Date: now.AddDate(0, 0, -60)},
+	})
+	c.SaveEntries("https://stale.example.com/feed", []cache.Entry{
+		{ID: "3", ChannelName: "Stale", Date: now.AddDate(0, 0, -60)},
+	})
+	c.SaveEntries("https://exempt.example.com/feed", []cache.Entry{
+		{ID: "4", ChannelName: "Exempt", Date: now.AddDate(0, 0, -60)},
+	})
+
+	cfg := &config.Config{
+		Planet: config.PlanetConfig{
+			CacheDirectory:    cacheDir,
+			DateFormat:        "2006-01-02",
+			ActivityThreshold: 30,
+		},
+		Feeds: []config.FeedConfig{
+			{URL: "https://active.example.com/feed", Name: "Active"},
+			{URL: "https://stale.example.com/feed", Name: "Stale"},
+			{URL: "https://exempt.example.com/feed", Name: "Exempt", Extra: map[string]string{"activity_threshold": "90"}},
+			{URL: "https://empty.example.com/feed", Name: "Empty"},
+		},
+	}
+
+	if err := New(outputDir).Render(tmplPath, nil, cfg); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "opml.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := string(content)
+
+	for _, want := range []string{
+		"Active inactive=false count=2 updated=" + now.AddDate(0, 0, -1).Format("2006-01-02"),
+		"Empty inactive=false count=0 updated=\n", // Not fetched yet
+		"Exempt inactive=false count=1",
+		"Stale inactive=true count=1",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+
+	// A template section can disable the check
+	cfg.Templates = map[string]config.TemplateConfig{tmplPath: {ActivityThreshold: 0}}
+	if err := New(outputDir).Render(tmplPath, nil, cfg); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	content, _ = os.ReadFile(filepath.Join(outputDir, "opml.xml"))
+	if strings.Contains(string(content), "inactive=true") {
+		t.Errorf("activity_threshold = 0 in the template section should disable the check:\n%s", content)
+	}
+}
+
+func TestRenderer_ExcerptContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputDir := filepath.Join(tmpDir, "output")
+	tmplPath := filepath.Join(tmpDir, "index.html.tmpl")
+
+	tmplContent := `{{range .Items}}{{.Content}}|{{.Excerpt}}|{{.Truncated}}|{{.WordCount}}|{{.ReadingTime}}{{end}}`
+	if err := os.WriteFile(tmplPath, []byte(tmplContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		Planet: config.PlanetConfig{
+			ItemsPerPage: 10,
+			DateFormat:   "2006-01-02",
+			ExcerptWords: 3,
+		},
+	}
+	entries := []cache.Entry{
+		{Title: "Long", Date: time.Now(), Content: "<p>One two three four five</p>"},
+	}
+
+	render := func() string {
+		t.Helper()
+		if err := New(outputDir).Render(tmplPath, entries, cfg); err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		content, err := os.ReadFile(filepath.Join(outputDir, "index.html"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+
+	if got, want := render(), "<p>One two three four five</p>|<p>One two three…</p>|true|5|1"; got != want {
+		t.Errorf("full content output = %q, want %q", got, want)
+	}
+
+	// content = excerpt with a template-specific limit
+	cfg.Templates = map[string]config.TemplateConfig{
+		tmplPath: {Content: "excerpt", ExcerptWords: 2, ExcerptChars: -1},
+	}
+	if got, want := render(), "<p>One two…</p>|<p>One two…</p>|true|5|1"; got != want {
+		t.Errorf("excerpt content output = %q, want %q", got, want)
+	}
+}
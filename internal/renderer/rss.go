@@ -0,0 +1,115 @@
+package renderer
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// RSS 2.0 document structure. Namespaced elements use literal prefixes
+// declared on the root element, as feed readers expect.
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	DCNS    string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title          string      `xml:"title"`
+	Link           string      `xml:"link"`
+	Description    string      `xml:"description"`
+	AtomLink       rssAtomLink `xml:"atom:link"`
+	LastBuildDate  string      `xml:"lastBuildDate,omitempty"`
+	Generator      string      `xml:"generator"`
+	ManagingEditor string      `xml:"managingEditor,omitempty"`
+	Items          []rssItem   `xml:"item"`
+}
+
+type rssAtomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type rssItem struct {
+	Title       string     `xml:"title,omitempty"`
+	Link        string     `xml:"link,omitempty"`
+	Description string     `xml:"description,omitempty"`
+	Author      string     `xml:"author,omitempty"`
+	Creator     string     `xml:"dc:creator,omitempty"`
+	GUID        rssGUID    `xml:"guid"`
+	PubDate     string     `xml:"pubDate,omitempty"`
+	Source      *rssSource `xml:"source,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	ID          string `xml:",chardata"`
+}
+
+type rssSource struct {
+	URL   string `xml:"url,attr"`
+	Title string `xml:",chardata"`
+}
+
+// writeRSS writes an RSS 2.0 feed of the entries, each with a source element
+// pointing to its original feed
+func writeRSS(w io.Writer, data TemplateData) error {
+	feed := rssFeed{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		DCNS:    "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         data.Name,
+			Link:          data.Link,
+			Description:   strings.TrimSuffix(data.Name+" - "+data.Link, " - "),
+			AtomLink:      rssAtomLink{Rel: "self", Type: "application/rss+xml", Href: feedURL(data, rssFile)},
+			LastBuildDate: rfc822(data.DateISO),
+			Generator:     data.Generator,
+			Items:         make([]rssItem, 0, len(data.Items)),
+		},
+	}
+	if data.OwnerEmail != "" {
+		feed.Channel.ManagingEditor = rssPerson(data.OwnerEmail, data.OwnerName)
+	}
+
+	for _, item := range data.Items {
+		entry := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: string(item.FullContent),
+			GUID:        rssGUID{IsPermaLink: item.ID == item.Link && item.Link != "", ID: item.ID},
+			PubDate:     item.Date822,
+		}
+		if entry.Title == "" && entry.Description == "" {
+			// An item needs a title or a description
+			entry.Title = item.ChannelName
+		}
+
+		// author must hold an email address; a bare name goes to dc:creator
+		author := entryAuthor(item)
+		if author.Email != "" {
+			entry.Author = rssPerson(author.Email, author.Name)
+		} else {
+			entry.Creator = author.Name
+		}
+
+		if item.ChannelURL != "" {
+			entry.Source = &rssSource{URL: item.ChannelURL, Title: channelTitle(item)}
+		}
+		feed.Channel.Items = append(feed.Channel.Items, entry)
+	}
+
+	return writeXML(w, feed)
+}
+
+// rssPerson formats a person as RSS expects: "email (name)"
+func rssPerson(email, name string) string {
+	if name == "" {
+		return email
+	}
+	return email + " (" + name + ")"
+}
@@ -0,0 +1,352 @@
+package sanitizer
+
+import (
+	"html"
+	"io"
+	"log/slog"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+// defaultTags are the elements allowed by the default policy
+var defaultTags = []string{
+	"a", "abbr", "acronym", "address", "b", "bdi", "bdo", "big", "blockquote", "br",
+	"caption", "center", "cite", "code", "col", "colgroup", "dd", "del", "details",
+	"dfn", "div", "dl", "dt", "em", "figcaption", "figure", "h1", "h2", "h3", "h4",
+	"h5", "h6", "hr", "i", "img", "ins", "kbd", "li", "mark", "ol", "p", "picture",
+	"pre", "q", "rp", "rt", "ruby", "s", "samp", "small", "span", "strike", "strong",
+	"sub", "summary", "sup", "table", "tbody", "td", "tfoot", "th", "thead", "time",
+	"tr", "tt", "u", "ul", "var", "wbr",
+}
+
+// defaultAttributes are the attributes allowed by the default policy, keyed by
+// element. The "*" key lists attributes allowed on every element.
+var defaultAttributes = map[string][]string{
+	"*":          {"title", "lang", "dir"},
+	"a":          {"href", "name"},
+	"img":        {"src", "alt", "width", "height"},
+	"blockquote": {"cite"},
+	"q":          {"cite"},
+	"del":        {"cite", "datetime"},
+	"ins":        {"cite", "datetime"},
+	"time":       {"datetime"},
+	"ol":         {"start", "type", "reversed"},
+	"li":         {"value"},
+	"col":        {"span"},
+	"colgroup":   {"span"},
+	"td":         {"colspan", "rowspan", "align", "valign"},
+	"th":         {"colspan", "rowspan", "align", "valign", "scope"},
+	"details":    {"open"},
+	"source":     {"src", "type", "media"},
+	"video":      {"src", "poster", "controls", "width", "height"},
+	"audio":      {"src", "controls"},
+	"iframe":     {"src", "width", "height", "allowfullscreen", "frameborder"},
+}
+
+// droppedWithContent are elements whose content is removed along with the tag
+// unless the element is explicitly allowed
+var droppedWithContent = map[string]bool{
+	"script": true, "style": true, "template": true, "iframe": true, "frame": true,
+	"frameset": true, "object": true, "embed": true, "applet": true, "noembed": true,
+	"noframes": true, "textarea": true, "select": true, "head": true, "title": true,
+	"svg": true, "math": true,
+}
+
+// urlAttributes hold URLs and are checked against the allowed schemes
+var urlAttributes = map[string]bool{
+	"href": true, "src": true, "cite": true, "poster": true,
+}
+
+// allowedSchemes are the URL schemes allowed in URL attributes
+var allowedSchemes = map[string]bool{
+	"http": true, "https": true, "mailto": true,
+}
+
+// voidElements never have an end tag
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true,
+	"img": true, "input": true, "link": true, "meta": true, "source": true,
+	"track": true, "wbr": true,
+}
+
+// Policy is an allowlist of elements and attributes
+type Policy struct {
+	tags  map[string]bool
+	attrs map[string]map[string]bool
+}
+
+// NewPolicy creates the default policy extended with extra tags and attributes.
+// Extra attributes are either global ("class") or element-specific ("img:loading").
+func NewPolicy(extraTags, extraAttributes []string) *Policy {
+	p := &Policy{
+		tags:  make(map[string]bool),
+		attrs: make(map[string]map[string]bool),
+	}
+	for _, tag := range defaultTags {
+		p.tags[tag] = true
+	}
+	for tag, attrs := range defaultAttributes {
+		for _, attr := range attrs {
+			p.allowAttribute(tag, attr)
+		}
+	}
+	return p.Allow(extraTags, extraAttributes)
+}
+
+// Allow returns a copy of the policy with additional tags and attributes allowed.
+// The receiver is left unchanged, so a global policy can be relaxed per feed.
+func (p *Policy) Allow(tags, attributes []string) *Policy {
+	relaxed := &Policy{
+		tags:  make(map[string]bool, len(p.tags)+len(tags)),
+		attrs: make(map[string]map[string]bool, len(p.attrs)),
+	}
+	for tag := range p.tags {
+		relaxed.tags[tag] = true
+	}
+	for tag, attrs := range p.attrs {
+		for attr := range attrs {
+			relaxed.allowAttribute(tag, attr)
+		}
+	}
+
+	for _, tag := range tags {
+		relaxed.tags[strings.ToLower(tag)] = true
+	}
+	for _, attr := range attributes {
+		tag, name, found := strings.Cut(strings.ToLower(attr), ":")
+		if !found {
+			tag, name = "*", tag
+		}
+		relaxed.allowAttribute(tag, name)
+	}
+
+	return relaxed
+}
+
+func (p *Policy) allowAttribute(tag, attr string) {
+	if p.attrs[tag] == nil {
+		p.attrs[tag] = make(map[string]bool)
+	}
+	p.attrs[tag][attr] = true
+}
+
+// attributeAllowed reports whether attr may be kept on tag.
+// Event handlers (on*) are never allowed, whatever the policy says.
+func (p *Policy) attributeAllowed(tag, attr string) bool {
+	if strings.HasPrefix(attr, "on") {
+		return false
+	}
+	return p.attrs["*"][attr] || p.attrs[tag][attr]
+}
+
+// Sanitize removes everything from an HTML fragment that the policy does not
+// allow. Disallowed elements are unwrapped (their text is kept), except for
+// script-like elements which are removed with their content. Open elements are
+// closed at the end so a broken entry cannot break the surrounding page.
+func (p *Policy) Sanitize(content string) string {
+	if content == "" {
+		return ""
+	}
+
+	var out strings.Builder
+	var open []string // stack of emitted, not yet closed elements
+	skip := ""        // element whose content is being dropped
+	skipDepth := 0
+
+	z := nethtml.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == nethtml.ErrorToken {
+			if z.Err() != io.EOF {
+				slog.Debug("error tokenizing entry content", "error", z.Err())
+			}
+			break
+		}
+
+		token := z.Token()
+		tag := token.Data
+
+		if skip != "" {
+			switch {
+			case tt == nethtml.StartTagToken && tag == skip:
+				skipDepth++
+			case tt == nethtml.EndTagToken && tag == skip:
+				skipDepth--
+				if skipDepth == 0 {
+					skip = ""
+				}
+			}
+			continue
+		}
+
+		switch tt {
+		case nethtml.TextToken:
+			out.WriteString(html.EscapeString(token.Data))
+
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			if !p.tags[tag] {
+				if droppedWithContent[tag] && tt == nethtml.StartTagToken {
+					skip = tag
+					skipDepth = 1
+				}
+				continue
+			}
+			p.writeStartTag(&out, token)
+			if tt == nethtml.StartTagToken && !voidElements[tag] {
+				open = append(open, tag)
+			}
+
+		case nethtml.EndTagToken:
+			// Only close elements we actually opened, closing any unclosed
+			// elements nested inside them first
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != tag {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					out.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+		// Comments and doctypes are dropped
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		out.WriteString("</" + open[i] + ">")
+	}
+
+	return out.String()
+}
+
+// writeStartTag writes a start tag keeping only allowed attributes
+func (p *Policy) writeStartTag(out *strings.Builder, token nethtml.Token) {
+	tag := token.Data
+	out.WriteString("<" + tag)
+	for _, attr := range token.Attr {
+		name := strings.ToLower(attr.Key)
+		if attr.Namespace != "" || !p.attributeAllowed(tag, name) {
+			continue
+		}
+		if urlAttributes[name] && !safeURL(tag, attr.Val) {
+			continue
+		}
+		out.WriteString(" " + name + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+	if voidElements[tag] {
+		out.WriteString(" />")
+	} else {
+		out.WriteString(">")
+	}
+}
+
+// safeURL reports whether a URL attribute value uses an allowed scheme.
+// Relative URLs are allowed; data: URLs only for inline images.
+func safeURL(tag, value string) bool {
+	// Browsers ignore whitespace and control characters inside the scheme
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, value)
+
+	colon := strings.IndexByte(cleaned, ':')
+	if colon < 0 || strings.ContainsAny(cleaned[:colon], "/?#") {
+		return true // Relative URL
+	}
+
+	scheme := strings.ToLower(cleaned[:colon])
+	if scheme == "data" {
+		return tag == "img" && strings.HasPrefix(strings.ToLower(cleaned), "data:image/")
+	}
+	return allowedSchemes[scheme]
+}
+
+// StripTags converts an HTML fragment such as an entry title into plain text:
+// HTML elements are removed and entities decoded. Angle-bracketed words that
+// are not HTML elements (e.g. "List<T>") are kept as written.
+func StripTags(s string) string {
+	if !strings.ContainsAny(s, "<&") {
+		return s
+	}
+
+	var out strings.Builder
+	z := nethtml.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		switch tt {
+		case nethtml.ErrorToken:
+			return strings.Join(strings.Fields(out.String()), " ")
+		case nethtml.TextToken:
+			out.Write(z.Text())
+		case nethtml.StartTagToken, nethtml.EndTagToken, nethtml.SelfClosingTagToken:
+			// Copy the raw tag first: TagName lowercases the buffer in place
+			raw := string(z.Raw())
+			name, _ := z.TagName()
+			switch atom.Lookup(name) {
+			case 0:
+				out.WriteString(html.UnescapeString(raw))
+			case atom.Br:
+				out.WriteString(" ")
+			}
+		}
+	}
+}
+
+// FromConfig builds the planet-wide policy from the [Planet] section
+func FromConfig(planet config.PlanetConfig) *Policy {
+	return NewPolicy(planet.SanitizeAllowTags, planet.SanitizeAllowAttributes)
+}
+
+// ApplyPerFeed sanitizes entry content and titles. Each feed uses the global
+// policy relaxed by its own sanitize_allow_tags/sanitize_allow_attributes;
+// a feed with "sanitize = false" keeps its content unchanged.
+// If global is nil only titles are converted to plain text.
+func ApplyPerFeed(entries []cache.Entry, feedConfigs []config.FeedConfig, global *Policy) []cache.Entry {
+	// Build a map of feed URL -> policy (nil means content is trusted)
+	feedPolicies := make(map[string]*Policy)
+	for _, feedConfig := range feedConfigs {
+		if global == nil || !feedConfig.Sanitize(true) {
+			feedPolicies[feedConfig.URL] = nil
+			continue
+		}
+
+		tags := feedConfig.SanitizeAllowTags()
+		attrs := feedConfig.SanitizeAllowAttributes()
+		if len(tags) == 0 && len(attrs) == 0 {
+			continue // Uses the global policy
+		}
+
+		feedPolicies[feedConfig.URL] = global.Allow(tags, attrs)
+		slog.Debug("created per-feed sanitize policy",
+			"feed", feedConfig.Name,
+			"url", feedConfig.URL,
+			"allow_tags", tags,
+			"allow_attributes", attrs)
+	}
+
+	sanitized := make([]cache.Entry, len(entries))
+	for i, entry := range entries {
+		policy, hasPolicy := feedPolicies[entry.ChannelURL]
+		if !hasPolicy {
+			policy = global
+		}
+
+		if policy != nil {
+			entry.Content = policy.Sanitize(entry.Content)
+		}
+		entry.Title = StripTags(entry.Title)
+		entry.ChannelTitle = StripTags(entry.ChannelTitle)
+
+		sanitized[i] = entry
+	}
+
+	return sanitized
+}
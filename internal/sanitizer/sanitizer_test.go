@@ -0,0 +1,151 @@
+package sanitizer
+
+import (
+	"testing"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+func TestPolicy_Sanitize(t *testing.T) {
+	policy := NewPolicy(nil, nil)
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "allowed markup kept",
+			input: `<p>Hello <a href="https://example.com/">world</a></p>`,
+			want:  `<p>Hello <a href="https://example.com/">world</a></p>`,
+		},
+		{
+			name:  "script removed with content",
+			input: `<p>Hi</p><script>alert("x")</script><p>there</p>`,
+			want:  `<p>Hi</p><p>there</p>`,
+		},
+		{
+			name:  "style removed with content",
+			input: `<style>body { display: none }</style>text`,
+			want:  `text`,
+		},
+		{
+			name:  "iframe removed by default",
+			input: `<iframe src="https://evil.example.com/"></iframe>after`,
+			want:  `after`,
+		},
+		{
+			name:  "event handlers stripped",
+			input: `<img src="a.png" onerror="alert(1)" alt="A">`,
+			want:  `<img src="a.png" alt="A" />`,
+		},
+		{
+			name:  "javascript URL stripped",
+			input: `<a href=" javascript:alert(1)">click</a>`,
+			want:  `<a>click</a>`,
+		},
+		{
+			name:  "unknown elements unwrapped",
+			input: `<form><button>Press</button></form>`,
+			want:  `Press`,
+		},
+		{
+			name:  "unclosed elements closed",
+			input: `<div><p>open`,
+			want:  `<div><p>open</p></div>`,
+		},
+		{
+			name:  "stray end tags dropped",
+			input: `text</div></body>`,
+			want:  `text`,
+		},
+		{
+			name:  "comments dropped and text escaped",
+			input: `<!-- hidden -->1 &lt; 2`,
+			want:  `1 &lt; 2`,
+		},
+		{
+			name:  "data image allowed on img only",
+			input: `<img src="data:image/png;base64,AAAA"><a href="data:text/html,x">x</a>`,
+			want:  `<img src="data:image/png;base64,AAAA" /><a>x</a>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.Sanitize(tt.input)
+			if got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_Allow(t *testing.T) {
+	global := NewPolicy(nil, []string{"class"})
+	relaxed := global.Allow([]string{"iframe"}, []string{"img:loading", "onclick"})
+
+	input := `<p class="x"><iframe src="https://www.youtube.com/embed/1" width="560"></iframe><img src="a.png" loading="lazy" onclick="x()"></p>`
+
+	if got, want := global.Sanitize(input), `<p class="x"><img src="a.png" /></p>`; got != want {
+		t.Errorf("global.Sanitize() = %q, want %q", got, want)
+	}
+
+	want := `<p class="x"><iframe src="https://www.youtube.com/embed/1" width="560"></iframe><img src="a.png" loading="lazy" /></p>`
+	if got := relaxed.Sanitize(input); got != want {
+		t.Errorf("relaxed.Sanitize() = %q, want %q", got, want)
+	}
+}
+
+func TestStripTags(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Plain title", "Plain title"},
+		{"<b>Bold</b> &amp; <i>italic</i>", "Bold & italic"},
+		{"Generics: List<T> in Java", "Generics: List<T> in Java"},
+		{"Line<br>break", "Line break"},
+	}
+
+	for _, tt := range tests {
+		if got := StripTags(tt.input); got != tt.want {
+			t.Errorf("StripTags(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestApplyPerFeed(t *testing.T) {
+	entries := []cache.Entry{
+		{Title: "<em>One</em>", Content: `<iframe src="https://video.example.com/1"></iframe>`, ChannelURL: "http://video.example.com/feed"},
+		{Title: "Two", Content: `<iframe src="https://evil.example.com/"></iframe>`, ChannelURL: "http://blog.example.com/feed"},
+		{Title: "Three", Content: `<script>trusted()</script>`, ChannelURL: "http://trusted.example.com/feed"},
+	}
+
+	feedConfigs := []config.FeedConfig{
+		{URL: "http://video.example.com/feed", Extra: map[string]string{"sanitize_allow_tags": "iframe"}},
+		{URL: "http://blog.example.com/feed", Extra: map[string]string{}},
+		{URL: "http://trusted.example.com/feed", Extra: map[string]string{"sanitize": "false"}},
+	}
+
+	sanitized := ApplyPerFeed(entries, feedConfigs, NewPolicy(nil, nil))
+
+	if sanitized[0].Title != "One" {
+		t.Errorf("sanitized[0].Title = %q, want %q", sanitized[0].Title, "One")
+	}
+	if sanitized[0].Content != `<iframe src="https://video.example.com/1"></iframe>` {
+		t.Errorf("per-feed relaxation not applied: %q", sanitized[0].Content)
+	}
+	if sanitized[1].Content != "" {
+		t.Errorf("global policy not applied: %q", sanitized[1].Content)
+	}
+	if sanitized[2].Content != `<script>trusted()</script>` {
+		t.Errorf("sanitize = false not honored: %q", sanitized[2].Content)
+	}
+
+	// Input entries must not be modified
+	if entries[0].Title != "<em>One</em>" {
+		t.Error("ApplyPerFeed modified its input")
+	}
+}
@@ -0,0 +1,205 @@
+package transform
+
+import (
+	"io"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/dedupe"
+)
+
+// urlAttributes hold a single URL
+var urlAttributes = map[string]bool{
+	"href": true, "src": true, "cite": true, "poster": true, "action": true,
+}
+
+// trackerHosts serve tracking pixels only
+var trackerHosts = map[string]bool{
+	"pixel.wp.com":             true,
+	"stats.wordpress.com":      true,
+	"www.google-analytics.com": true,
+	"pixel.quantserve.com":     true,
+	"feeds.feedburner.com":     true,
+}
+
+// rewriteTags passes the start tags of an HTML fragment to fn, which may
+// modify the token or return false to drop the tag. Everything else,
+// including unmodified tags, is copied as written.
+func rewriteTags(content string, fn func(token *nethtml.Token) bool) string {
+	if !strings.Contains(content, "<") {
+		return content
+	}
+
+	var out strings.Builder
+	z := nethtml.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == nethtml.ErrorToken {
+			if z.Err() != io.EOF {
+				slog.Debug("error tokenizing entry content", "error", z.Err())
+			}
+			return out.String()
+		}
+		if tt != nethtml.StartTagToken && tt != nethtml.SelfClosingTagToken {
+			out.Write(z.Raw())
+			continue
+		}
+
+		// Copy the raw tag first: Token reuses the tokenizer's buffer
+		raw := string(z.Raw())
+		token := z.Token()
+		before := token.String()
+		if !fn(&token) {
+			continue
+		}
+		if after := token.String(); after != before {
+			out.WriteString(after)
+		} else {
+			out.WriteString(raw)
+		}
+	}
+}
+
+// attr returns the value of a token's attribute
+func attr(token *nethtml.Token, key string) (string, bool) {
+	for _, a := range token.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, key) {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// absoluteURLs resolves relative URLs in the content against the entry link,
+// or the feed's site link if the entry has none
+func absoluteURLs(entry *cache.Entry) {
+	base, err := url.Parse(entry.ChannelLink)
+	if err != nil {
+		base = nil
+	}
+	if entry.Link != "" {
+		if link, err := url.Parse(entry.Link); err == nil {
+			if base != nil {
+				link = base.ResolveReference(link)
+			}
+			entry.Link = link.String()
+			if link.IsAbs() {
+				base = link
+			}
+		}
+	}
+	if base == nil || !base.IsAbs() {
+		return
+	}
+
+	resolve := func(value string) string {
+		trimmed := strings.TrimSpace(value)
+		ref, err := url.Parse(trimmed)
+		if err != nil || ref.IsAbs() || trimmed == "" {
+			return value
+		}
+		return base.ResolveReference(ref).String()
+	}
+
+	entry.Content = rewriteTags(entry.Content, func(token *nethtml.Token) bool {
+		for i, a := range token.Attr {
+			switch key := strings.ToLower(a.Key); {
+			case a.Namespace != "":
+			case urlAttributes[key]:
+				token.Attr[i].Val = resolve(a.Val)
+			case key == "srcset":
+				candidates := strings.Split(a.Val, ",")
+				for j, candidate := range candidates {
+					fields := strings.Fields(candidate)
+					if len(fields) > 0 {
+						fields[0] = resolve(fields[0])
+						candidates[j] = strings.Join(fields, " ")
+					}
+				}
+				token.Attr[i].Val = strings.Join(candidates, ", ")
+			}
+		}
+		return true
+	})
+}
+
+// stripTrackers removes tracking pixels from the content and tracking
+// parameters such as utm_source from links
+func stripTrackers(entry *cache.Entry) {
+	entry.Link = stripTrackingParams(entry.Link)
+
+	entry.Content = rewriteTags(entry.Content, func(token *nethtml.Token) bool {
+		if token.Data == "img" && trackingPixel(token) {
+			return false
+		}
+		for i, a := range token.Attr {
+			if a.Namespace == "" && urlAttributes[strings.ToLower(a.Key)] {
+				token.Attr[i].Val = stripTrackingParams(a.Val)
+			}
+		}
+		return true
+	})
+}
+
+// trackingPixel reports whether an image is a tracking pixel: at most 1x1
+// pixels, or served by a tracking host
+func trackingPixel(token *nethtml.Token) bool {
+	width, hasWidth := attr(token, "width")
+	height, hasHeight := attr(token, "height")
+	if hasWidth && hasHeight && tinyDimension(width) && tinyDimension(height) {
+		return true
+	}
+
+	src, _ := attr(token, "src")
+	u, err := url.Parse(strings.TrimSpace(src))
+	return err == nil && trackerHosts[strings.ToLower(u.Hostname())]
+}
+
+// tinyDimension reports whether a width or height attribute is 0 or 1 pixel
+func tinyDimension(value string) bool {
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(value), "px"))
+	return err == nil && n <= 1
+}
+
+// stripTrackingParams removes tracking query parameters from a URL. URLs
+// without any are returned unchanged.
+func stripTrackingParams(link string) string {
+	if !strings.Contains(link, "?") {
+		return link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+
+	query := u.Query()
+	removed := false
+	for key := range query {
+		if dedupe.IsTrackingParam(key) {
+			query.Del(key)
+			removed = true
+		}
+	}
+	if !removed {
+		return link
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// lazyImages adds loading="lazy" to images that do not set a loading mode
+func lazyImages(entry *cache.Entry) {
+	entry.Content = rewriteTags(entry.Content, func(token *nethtml.Token) bool {
+		if token.Data == "img" {
+			if _, ok := attr(token, "loading"); !ok {
+				token.Attr = append(token.Attr, nethtml.Attribute{Key: "loading", Val: "lazy"})
+			}
+		}
+		return true
+	})
+}
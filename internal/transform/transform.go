@@ -0,0 +1,104 @@
+// Package transform rewrites entries between loading them from the cache and
+// rendering them, e.g. to make relative links in the content absolute.
+package transform
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+// Transformer rewrites an entry in place
+type Transformer interface {
+	Transform(entry *cache.Entry)
+}
+
+// Func adapts a function to the Transformer interface
+type Func func(entry *cache.Entry)
+
+// Transform calls f(entry)
+func (f Func) Transform(entry *cache.Entry) {
+	f(entry)
+}
+
+// Names of the built-in transformers
+const (
+	AbsoluteURLs  = "absolute_urls"  // Resolve relative URLs against the entry or feed link
+	StripTrackers = "strip_trackers" // Remove tracking pixels and tracking query parameters
+	LazyImages    = "lazy_images"    // Add loading="lazy" to images
+)
+
+// builtins are the transformers that can be enabled in the config
+var builtins = map[string]Transformer{
+	AbsoluteURLs:  Func(absoluteURLs),
+	StripTrackers: Func(stripTrackers),
+	LazyImages:    Func(lazyImages),
+}
+
+// Names returns the names of the built-in transformers, sorted
+func Names() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the built-in transformers with the given names, in order
+func Lookup(names []string) ([]Transformer, error) {
+	transformers := make([]Transformer, 0, len(names))
+	for _, name := range names {
+		transformer, ok := builtins[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown transformer %q (available: %s)", name, strings.Join(Names(), ", "))
+		}
+		transformers = append(transformers, transformer)
+	}
+	return transformers, nil
+}
+
+// ApplyPerFeed runs each entry through the transformers enabled for its feed:
+// the feed's own transformers setting, or the global one if it has none.
+func ApplyPerFeed(entries []cache.Entry, feedConfigs []config.FeedConfig, global []string) ([]cache.Entry, error) {
+	globalChain, err := Lookup(global)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build a map of feed URL -> transformers
+	feedChains := make(map[string][]Transformer)
+	for _, feedConfig := range feedConfigs {
+		names := feedConfig.Transformers(global)
+		chain, err := Lookup(names)
+		if err != nil {
+			return nil, fmt.Errorf("transformers for feed %s: %w", feedConfig.URL, err)
+		}
+		feedChains[feedConfig.URL] = chain
+
+		if len(names) > 0 {
+			slog.Debug("transformers enabled",
+				"feed", feedConfig.Name,
+				"url", feedConfig.URL,
+				"transformers", names)
+		}
+	}
+
+	transformed := make([]cache.Entry, len(entries))
+	for i, entry := range entries {
+		chain, ok := feedChains[entry.ChannelURL]
+		if !ok {
+			chain = globalChain
+		}
+		for _, transformer := range chain {
+			transformer.Transform(&entry)
+		}
+		transformed[i] = entry
+	}
+
+	return transformed, nil
+}
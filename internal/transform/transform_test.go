@@ -0,0 +1,110 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/alexey-ott/planet-go/internal/cache"
+	"github.com/alexey-ott/planet-go/internal/config"
+)
+
+func TestAbsoluteURLs(t *testing.T) {
+	entry := cache.Entry{
+		Link:        "/2024/01/post.html",
+		ChannelLink: "https://blog.example.com/",
+		Content: `<p><a href="../about">About</a> <a href="https://other.example.com/x">x</a> <a href="#notes">notes</a></p>` +
+			`<img src="img/a.png" srcset="img/a.png 1x, //cdn.example.com/a@2x.png 2x" />` +
+			`<a href="mailto:me@example.com">mail</a>`,
+	}
+
+	absoluteURLs(&entry)
+
+	if want := "https://blog.example.com/2024/01/post.html"; entry.Link != want {
+		t.Errorf("Link = %q, want %q", entry.Link, want)
+	}
+	want := `<p><a href="https://blog.example.com/2024/about">About</a> <a href="https://other.example.com/x">x</a> <a href="https://blog.example.com/2024/01/post.html#notes">notes</a></p>` +
+		`<img src="https://blog.example.com/2024/01/img/a.png" srcset="https://blog.example.com/2024/01/img/a.png 1x, https://cdn.example.com/a@2x.png 2x"/>` +
+		`<a href="mailto:me@example.com">mail</a>`
+	if entry.Content != want {
+		t.Errorf("Content =\n%s\nwant\n%s", entry.Content, want)
+	}
+
+	// Without an absolute base the content is left alone
+	relative := cache.Entry{Link: "/post", Content: `<a href="/x">x</a>`}
+	absoluteURLs(&relative)
+	if relative.Content != `<a href="/x">x</a>` {
+		t.Errorf("Content without base = %q", relative.Content)
+	}
+}
+
+func TestStripTrackers(t *testing.T) {
+	entry := cache.Entry{
+		Link: "https://blog.example.com/post?id=7&utm_source=rss&utm_medium=feed",
+		Content: `<p>Text <a href="https://example.com/?a=1&amp;fbclid=xyz">link</a></p>` +
+			`<img src="https://blog.example.com/photo.jpg" width="640" height="480">` +
+			`<img src="https://blog.example.com/track.gif" width="1" height="1">` +
+			`<img src="https://pixel.wp.com/g.gif?v=1">` +
+			`<img src="https://feeds.feedburner.com/~r/blog/~4/abc" height="1px" width="1px"/>`,
+	}
+
+	stripTrackers(&entry)
+
+	if want := "https://blog.example.com/post?id=7"; entry.Link != want {
+		t.Errorf("Link = %q, want %q", entry.Link, want)
+	}
+	want := `<p>Text <a href="https://example.com/?a=1">link</a></p>` +
+		`<img src="https://blog.example.com/photo.jpg" width="640" height="480">`
+	if entry.Content != want {
+		t.Errorf("Content =\n%s\nwant\n%s", entry.Content, want)
+	}
+}
+
+func TestLazyImages(t *testing.T) {
+	entry := cache.Entry{
+		Content: `<p>Photo: <img src="a.png" alt="A &amp; B" /> <img src="b.png" loading="eager"></p>`,
+	}
+
+	lazyImages(&entry)
+
+	want := `<p>Photo: <img src="a.png" alt="A &amp; B" loading="lazy"/> <img src="b.png" loading="eager"></p>`
+	if entry.Content != want {
+		t.Errorf("Content = %q, want %q", entry.Content, want)
+	}
+}
+
+func TestApplyPerFeed(t *testing.T) {
+	content := `<img src="a.png">`
+	entries := []cache.Entry{
+		{ChannelURL: "https://blog1.com/feed", ChannelLink: "https://blog1.com/", Content: content},
+		{ChannelURL: "https://blog2.com/feed", ChannelLink: "https://blog2.com/", Content: content},
+		{ChannelURL: "https://blog3.com/feed", ChannelLink: "https://blog3.com/", Content: content},
+	}
+	feedConfigs := []config.FeedConfig{
+		{URL: "https://blog1.com/feed", Extra: map[string]string{}},
+		{URL: "https://blog2.com/feed", Extra: map[string]string{"transformers": "absolute_urls lazy_images"}},
+		{URL: "https://blog3.com/feed", Extra: map[string]string{"transformers": ""}},
+	}
+
+	transformed, err := ApplyPerFeed(entries, feedConfigs, []string{LazyImages})
+	if err != nil {
+		t.Fatalf("ApplyPerFeed() error = %v", err)
+	}
+
+	want := []string{
+		`<img src="a.png" loading="lazy">`,
+		`<img src="https://blog2.com/a.png" loading="lazy">`,
+		content,
+	}
+	for i := range want {
+		if transformed[i].Content != want[i] {
+			t.Errorf("transformed[%d].Content = %q, want %q", i, transformed[i].Content, want[i])
+		}
+	}
+	if entries[0].Content != content {
+		t.Error("ApplyPerFeed() modified its input")
+	}
+
+	feedConfigs[0].Extra["transformers"] = "minify"
+	if _, err := ApplyPerFeed(entries, feedConfigs, nil); err == nil {
+		t.Error("ApplyPerFeed() with an unknown transformer succeeded")
+	}
+}
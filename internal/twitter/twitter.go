@@ -15,6 +15,7 @@ import (
 	"github.com/michimani/gotwi/tweet/managetweet"
 	"github.com/michimani/gotwi/tweet/managetweet/types"
 
+	"github.com/alexey-ott/planet-go/internal/atomicfile"
 	"github.com/alexey-ott/planet-go/internal/cache"
 	"github.com/alexey-ott/planet-go/internal/config"
 )
@@ -29,8 +30,13 @@ type PostedArticle struct {
 	TweetText string    `json:"tweet_text,omitempty"`
 }
 
+// trackingVersion is the current tracking file format version.
+// Version 1 uses stable entry IDs (see cache.StableID).
+const trackingVersion = 1
+
 // TrackingData stores all posted articles
 type TrackingData struct {
+	Version  int             `json:"version"`
 	Articles []PostedArticle `json:"articles"`
 }
 
@@ -84,6 +90,7 @@ func (p *Poster) loadTracking() (*TrackingData, error) {
 
 	if _, err := os.Stat(p.trackingFile); os.IsNotExist(err) {
 		slog.Info("Tracking file does not exist, will be created", "path", p.trackingFile)
+		data.Version = trackingVersion
 		return data, nil
 	}
 
@@ -94,6 +101,7 @@ func (p *Poster) loadTracking() (*TrackingData, error) {
 
 	if len(content) == 0 {
 		slog.Info("Tracking file is empty, starting fresh")
+		data.Version = trackingVersion
 		return data, nil
 	}
 
@@ -101,6 +109,14 @@ func (p *Poster) loadTracking() (*TrackingData, error) {
 		return nil, fmt.Errorf("unmarshal tracking data: %w", err)
 	}
 
+	if data.Version < trackingVersion {
+		migrated := migrateTrackingIDs(data)
+		slog.Info("Migrated tracking data to stable entry IDs", "rekeyed_articles", migrated)
+		if err := p.saveTracking(data); err != nil {
+			return nil, fmt.Errorf("save migrated tracking data: %w", err)
+		}
+	}
+
 	slog.Info("Loaded tracking data", "posted_articles", len(data.Articles))
 	return data, nil
 }
@@ -118,7 +134,7 @@ func (p *Poster) saveTracking(data *TrackingData) error {
 		return fmt.Errorf("marshal tracking data: %w", err)
 	}
 
-	if err := os.WriteFile(p.trackingFile, content, 0644); err != nil {
+	if err := atomicfile.WriteFile(p.trackingFile, content, 0644); err != nil {
 		return fmt.Errorf("write tracking file: %w", err)
 	}
 
@@ -126,6 +142,21 @@ func (p *Poster) saveTracking(data *TrackingData) error {
 	return nil
 }
 
+// migrateTrackingIDs re-keys articles posted without an entry ID to the stable
+// ID the fetcher now gives such entries (their link). It returns the number of
+// re-keyed articles.
+func migrateTrackingIDs(data *TrackingData) int {
+	migrated := 0
+	for i, article := range data.Articles {
+		if link := strings.TrimSpace(article.Link); article.ID == "" && link != "" {
+			data.Articles[i].ID = link
+			migrated++
+		}
+	}
+	data.Version = trackingVersion
+	return migrated
+}
+
 // isPosted checks if an article has already been posted
 func (p *Poster) isPosted(entryID string, tracking *TrackingData) bool {
 	for _, article := range tracking.Articles {
@@ -1,6 +1,9 @@
 package twitter
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -182,6 +185,47 @@ func TestIsPosted(t *testing.T) {
 	}
 }
 
+func TestLoadTracking_MigratesIDs(t *testing.T) {
+	trackingFile := filepath.Join(t.TempDir(), "tracking.json")
+	legacy := `{"articles": [
+  {"id": "guid-1", "link": "https://example.com/1"},
+  {"id": "", "link": "https://example.com/2"},
+  {"id": "", "link": ""}
+]}`
+	if err := os.WriteFile(trackingFile, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	poster := &Poster{trackingFile: trackingFile}
+	tracking, err := poster.loadTracking()
+	if err != nil {
+		t.Fatalf("loadTracking() error = %v", err)
+	}
+
+	wantIDs := []string{"guid-1", "https://example.com/2", ""}
+	for i, article := range tracking.Articles {
+		if article.ID != wantIDs[i] {
+			t.Errorf("articles[%d].ID = %q, want %q", i, article.ID, wantIDs[i])
+		}
+	}
+	if !poster.isPosted("https://example.com/2", tracking) {
+		t.Error("entry with link ID is not recognized as posted after migration")
+	}
+
+	// The migrated data is saved with the current version
+	content, err := os.ReadFile(trackingFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved TrackingData
+	if err := json.Unmarshal(content, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Version != trackingVersion || saved.Articles[1].ID != "https://example.com/2" {
+		t.Errorf("saved tracking data not migrated: %+v", saved)
+	}
+}
+
 func TestTwitterHandleExtraction(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -103,7 +103,7 @@ func TestIntegration_FullPipeline(t *testing.T) {
 	}
 
 	// Step 2: Load all entries from cache
-	entries, err := cache.LoadAll()
+	entries, err := cache.LoadAll(nil)
 	if err != nil {
 		t.Fatalf("failed to load entries: %v", err)
 	}
@@ -322,7 +322,7 @@ func TestIntegration_MultipleFeeds(t *testing.T) {
 	}
 
 	// Load all entries
-	entries, err := cache.LoadAll()
+	entries, err := cache.LoadAll(nil)
 	if err != nil {
 		t.Fatalf("failed to load entries: %v", err)
 	}